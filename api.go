@@ -1,268 +1,1236 @@
 package main
 
 import (
-	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net"
-	"os"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ardielle/ardielle-go/rdl"
 	"gitlab.com/cty3000/superman-detector/supermandetector"
 
-	_ "github.com/mattn/go-sqlite3"
-	"github.com/oschwald/geoip2-golang"
 	"github.com/umahmood/haversine"
 )
 
+var (
+	// ErrInvalidIpAddress is returned when the ip address of the request is not parsable
+	ErrInvalidIpAddress = errors.New("invalid ip address")
+	// ErrGeoNotFound is returned when the ip address has no location in GeoLite2 City database
+	ErrGeoNotFound = errors.New("ip address is not found in the geolocation database")
+	// ErrGeoUnresolvable is returned when the ip address is not a public address to be located, e.g. a private or loopback one
+	ErrGeoUnresolvable = errors.New("ip address is not a public address")
+	// ErrEventConflict is returned when the event uuid is already registered for a different access
+	ErrEventConflict = errors.New("event is already registered with a different payload")
+)
+
+// maxBatchSize is the maximum number of the requests in a batch, not to hold the transaction too long
+const maxBatchSize = 1000
+
+// maxTimelineLimit is the maximum number of the records in a page of the timeline
+const maxTimelineLimit = 1000
+
+// milesPerKilometer is a ratio to convert the accuracy radius of GeoLite2 in kilometers into miles
+const milesPerKilometer = 0.621371
+
 type SupermanDetectorImpl struct {
-	baseUrl        string
-	ipaccessdb     *sql.DB
-	geodb          *geoip2.Reader
-	speedThreshold int32
+	baseUrl  string
+	store    AccessStore
+	geodb    *GeoDB
+	geo      GeoResolverChain
+	policies *PolicyStore
+	audit    *AuditLog
+	// overrides is the corporate override table asked before the providers, nil if not configured
+	overrides *StaticGeoResolver
+	// network classifies the network of the ip addresses, nil if no list is configured
+	network *NetworkClassifier
+	// suspiciousNetworks are the kinds of the networks which make an access suspicious
+	suspiciousNetworks []string
+	// asn looks up the autonomous system of the ip addresses, nil if no database is present
+	asn *ASNLookup
+	// trustedSameASN are the connection types, or * for any, of the accesses trusted to travel within the same autonomous system
+	trustedSameASN []string
+	// geodbWatchInterval is how often the GeoLite2 City database file is checked to be reloaded, 0 not to watch it
+	geodbWatchInterval time.Duration
+	// retention is the retention policy of the records, which purger enforces once started if any records expire
+	retention *RetentionPolicy
+	purger    *Purger
+	// authns are passed to the adaptor to authenticate the requests by the credentials in the headers
+	authns []rdl.Authenticator
+	// authRequired rejects the anonymous requests once any credentials are configured
+	authRequired bool
+	// admins are the principals authorized to administer the speed policies and the user data
+	admins []string
 }
 
 // NewSupermanDetectorImpl is an implementation to initialize a SupermanDetectorImpl
-func NewSupermanDetectorImpl(baseUrl string) (*SupermanDetectorImpl, error) {
+func NewSupermanDetectorImpl(baseUrl string, config *Config) (*SupermanDetectorImpl, error) {
 	var err error
 
 	impl := new(SupermanDetectorImpl)
-	impl.geodb, err = impl.InitGeoDB()
+	if config.HasGeoProvider(GeoProviderMaxMind) {
+		impl.geodb, impl.geodbWatchInterval, err = impl.InitGeoDB(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	impl.overrides, err = impl.InitGeoOverrides(config)
+	if err != nil {
+		return nil, err
+	}
+	impl.geo, err = impl.InitGeoResolver(config)
+	if err != nil {
+		return nil, err
+	}
+	impl.network, impl.suspiciousNetworks, err = impl.InitNetworkClassifier(config)
 	if err != nil {
 		return nil, err
 	}
-	impl.ipaccessdb, err = impl.InitIPAccessDB()
+	impl.asn, err = NewASNLookup(config)
 	if err != nil {
 		return nil, err
 	}
+	impl.trustedSameASN = config.TrustedSameASN
+	impl.store, err = impl.InitAccessStore(config)
+	if err != nil {
+		return nil, err
+	}
+	impl.policies, err = impl.InitPolicyStore(config)
+	if err != nil {
+		return nil, err
+	}
+	impl.audit, err = NewAuditLog(config.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+	impl.retention, impl.purger, err = impl.InitPurger(config)
+	if err != nil {
+		return nil, err
+	}
+	authConfig, err := LoadAuthConfig(config.AuthFile)
+	if err != nil {
+		return nil, err
+	}
+	impl.authns, err = authConfig.Authenticators()
+	if err != nil {
+		return nil, err
+	}
+	impl.admins = authConfig.Admins
+	impl.authRequired = len(impl.authns) > 0 || config.TLSClientCA != ""
+	if !impl.authRequired {
+		log.Printf("No credentials are configured, accepting anonymous requests\n")
+	} else if len(impl.admins) == 0 {
+		log.Printf("No admins are configured, denying the administration of the speed policies and the user data\n")
+	}
 
 	impl.baseUrl = baseUrl
-	impl.speedThreshold = 500
 
 	return impl, nil
 }
 
-// InitGeoDB is an implementation to make a connection with GeoLite2 City database
-func (impl *SupermanDetectorImpl) InitGeoDB() (*geoip2.Reader, error) {
-	db, err := geoip2.Open("GeoLite2-City.mmdb")
+// InitGeoDB is an implementation to make a connection with GeoLite2 City database at the path of the configuration,
+// and to get how often the file is checked to be reloaded
+func (impl *SupermanDetectorImpl) InitGeoDB(config *Config) (*GeoDB, time.Duration, error) {
+	db, err := OpenGeoDB(config.GeoDBPath)
 	if err != nil {
-		defer db.Close()
-		return nil, err
+		return nil, 0, err
+	}
+	interval, err := time.ParseDuration(config.GeoDBWatchInterval)
+	if err != nil || interval < 0 {
+		db.Close()
+		return nil, 0, fmt.Errorf("invalid geodb watch interval: %s", config.GeoDBWatchInterval)
 	}
 
-	return db, nil
+	return db, interval, nil
 }
 
-// InitIPAccessDB is an implementation to initialize sqlite database for ip access record
-func (impl *SupermanDetectorImpl) InitIPAccessDB() (*sql.DB, error) {
-	os.Remove("./ipaccess.db")
+// InitGeoOverrides is an implementation to load the corporate override table of the configuration, nil if not configured
+func (impl *SupermanDetectorImpl) InitGeoOverrides(config *Config) (*StaticGeoResolver, error) {
+	if config.GeoOverrideFile == "" {
+		return nil, nil
+	}
+
+	return LoadStaticGeoResolver(GeoProviderOverride, config.GeoOverrideFile)
+}
 
-	db, err := sql.Open("sqlite3", "./ipaccess.db")
+// InitGeoResolver is an implementation to compose the corporate override table and the geolocation providers of the configuration in the order of the priority
+func (impl *SupermanDetectorImpl) InitGeoResolver(config *Config) (GeoResolverChain, error) {
+	chain, err := NewGeoResolverChain(config, impl.geodb)
 	if err != nil {
-		defer db.Close()
 		return nil, err
 	}
+	if impl.overrides != nil {
+		chain = append(GeoResolverChain{impl.overrides}, chain...)
+	}
+
+	return chain, nil
+}
 
-	sqlStmt := `
-	create table ipaccess (username text not null, unix_timestamp integer not null, event_uuid text not null primary key, ip_address text not null, lat real not null, lon real not null, radius not null);
-	delete from ipaccess;
-	`
-	_, err = db.Exec(sqlStmt)
+// InitNetworkClassifier is an implementation to load the lists to classify the networks of the configuration,
+// and to get the kinds of the networks which make an access suspicious
+func (impl *SupermanDetectorImpl) InitNetworkClassifier(config *Config) (*NetworkClassifier, []string, error) {
+	for _, kind := range config.SuspiciousNetworks {
+		switch kind {
+		case NetworkTor, NetworkAnonymizer, NetworkHosting:
+		default:
+			return nil, nil, fmt.Errorf("unknown suspicious network: %s", kind)
+		}
+	}
+	network, err := NewNetworkClassifier(config)
 	if err != nil {
-		log.Printf("%q: %s\n", err, sqlStmt)
-		return nil, err
+		return nil, nil, err
 	}
 
-	return db, nil
+	return network, config.SuspiciousNetworks, nil
 }
 
-// IpAccessRequest2CurrentGeo is an implementation to obtain a current geolocation from the request information
-func (impl *SupermanDetectorImpl) IpAccessRequest2CurrentGeo(request *supermandetector.IpAccessRequest) (*supermandetector.CurrentGeo, error) {
-	// If you are using strings that may be invalid, check that ip is not nil
-	ip := net.ParseIP(string(request.Ip_address))
-	city, err := impl.geodb.City(ip)
-	if err != nil {
-		return nil, err
+// maxMindDBs lists the MaxMind databases which are open
+func (impl *SupermanDetectorImpl) maxMindDBs() []*GeoDB {
+	dbs := []*GeoDB{}
+	if impl.geodb != nil {
+		dbs = append(dbs, impl.geodb)
+	}
+	if impl.network != nil && impl.network.anonymousIP != nil {
+		dbs = append(dbs, impl.network.anonymousIP)
+	}
+	if impl.asn != nil {
+		for _, db := range []*GeoDB{impl.asn.asn, impl.asn.connectionType} {
+			if db != nil {
+				dbs = append(dbs, db)
+			}
+		}
 	}
 
-	return supermandetector.NewCurrentGeo(&supermandetector.CurrentGeo{
-		Lat:    float64(city.Location.Latitude),
-		Lon:    float64(city.Location.Longitude),
-		Radius: int32(city.Location.AccuracyRadius),
-	}), nil
+	return dbs
 }
 
-// GenerateIpAccessRecord is an implementation to generate a registerable struct as IpAccessRecord from the current geolocation and the request information
-func (impl *SupermanDetectorImpl) GenerateIpAccessRecord(request *supermandetector.IpAccessRequest, currentGeo *supermandetector.CurrentGeo) *supermandetector.IpAccessRecord {
-	return supermandetector.NewIpAccessRecord(&supermandetector.IpAccessRecord{
-		Username:       request.Username,
-		Unix_timestamp: request.Unix_timestamp,
-		Event_uuid:     request.Event_uuid,
-		Ip_address:     request.Ip_address,
-		Lat:            currentGeo.Lat,
-		Lon:            currentGeo.Lon,
-		Radius:         currentGeo.Radius,
-	})
+// StartGeoDBWatcher is an implementation to start reloading the MaxMind databases in background once the files are updated
+func (impl *SupermanDetectorImpl) StartGeoDBWatcher() {
+	if impl.geodbWatchInterval == 0 {
+		log.Printf("No geodb watch interval is configured, reloading MaxMind databases only on SIGHUP\n")
+		return
+	}
+	for _, db := range impl.maxMindDBs() {
+		db.Watch(impl.geodbWatchInterval)
+	}
 }
 
-// RegisterIpAccessRecord is an implementation to register ip access to database as a record
-func (impl *SupermanDetectorImpl) RegisterIpAccessRecord(ipRecord *supermandetector.IpAccessRecord) error {
-	tx, err := impl.ipaccessdb.Begin()
+// ReloadGeo is an implementation to reload the MaxMind databases, the override tables and the lists of the networks from the files,
+// keeping the current ones on failure
+func (impl *SupermanDetectorImpl) ReloadGeo() error {
+	var err error
+	for _, db := range impl.maxMindDBs() {
+		if e := db.Reload(); err == nil {
+			err = e
+		}
+	}
+	if e := impl.geo.Reload(); err == nil {
+		err = e
+	}
+	if impl.network != nil {
+		if e := impl.network.Reload(); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// InitAccessStore is an implementation to open the store for ip access record chosen by the configuration
+func (impl *SupermanDetectorImpl) InitAccessStore(config *Config) (AccessStore, error) {
+	return NewAccessStore(config.StorageDriver, config.StorageDSN)
+}
+
+// InitPolicyStore is an implementation to initialize the speed policies with the default threshold and unit of the configuration
+func (impl *SupermanDetectorImpl) InitPolicyStore(config *Config) (*PolicyStore, error) {
+	unit, err := ParseSpeedUnit(config.SpeedUnit)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	stmt, err := tx.Prepare("insert into ipaccess(username, unix_timestamp, event_uuid, ip_address, lat, lon, radius) values(?, ?, ?, ?, ?, ?, ?)")
+	return NewPolicyStore(&supermandetector.SpeedPolicy{
+		Threshold: config.SpeedThreshold,
+		Unit:      unit,
+	}, config.PolicyFile)
+}
+
+// InitPurger is an implementation to initialize the retention policy of the configuration and the purger to enforce it, nil if no records expire
+func (impl *SupermanDetectorImpl) InitPurger(config *Config) (*RetentionPolicy, *Purger, error) {
+	policy, err := NewRetentionPolicy(config)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	if !policy.Enabled() {
+		return policy, nil, nil
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(ipRecord.Username, ipRecord.Unix_timestamp, ipRecord.Event_uuid, ipRecord.Ip_address, ipRecord.Lat, ipRecord.Lon, ipRecord.Radius)
+	interval, err := time.ParseDuration(config.RetentionInterval)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, nil, err
+	}
+	purger, err := NewPurger(impl.store, policy, interval, config.RetentionBatchSize)
+	if err != nil {
+		return nil, nil, err
 	}
-	tx.Commit()
 
-	return nil
+	return policy, purger, nil
+}
+
+// StartPurger is an implementation to start purging the expired records in background if any records expire
+func (impl *SupermanDetectorImpl) StartPurger() {
+	if impl.purger == nil {
+		log.Printf("No retention is configured, keeping IpAccessRecords forever\n")
+		return
+	}
+	impl.purger.Start()
+}
+
+// Authenticators is an implementation to get the authenticators of the credentials file
+func (impl *SupermanDetectorImpl) Authenticators() []rdl.Authenticator {
+	return impl.authns
+}
+
+// Close is an implementation to stop the purger and to release the store for ip access record, the GeoLite2 City database and the audit log
+func (impl *SupermanDetectorImpl) Close() error {
+	var err error
+	if impl.purger != nil {
+		impl.purger.Stop()
+	}
+	if impl.store != nil {
+		err = impl.store.Close()
+	}
+	if impl.geodb != nil {
+		if e := impl.geodb.Close(); err == nil {
+			err = e
+		}
+	}
+	if impl.network != nil {
+		if e := impl.network.Close(); err == nil {
+			err = e
+		}
+	}
+	if impl.asn != nil {
+		if e := impl.asn.Close(); err == nil {
+			err = e
+		}
+	}
+	if impl.audit != nil {
+		if e := impl.audit.Close(); err == nil {
+			err = e
+		}
+	}
+
+	return err
 }
 
-// GetSubsequentIpAccess is an implementation to get a nearest preceding ip access from current ip access
+// NormalizeIpAddress is an implementation to normalise an IPv4 or IPv6 address into its canonical text form,
+// so that the same address is stored and compared alike however it is written, e.g. an IPv4-mapped IPv6 address as IPv4
+func NormalizeIpAddress(ipAddress supermandetector.IPAddress) (supermandetector.IPAddress, error) {
+	ip := net.ParseIP(string(ipAddress))
+	if ip == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidIpAddress, ipAddress)
+	}
+
+	return supermandetector.IPAddress(ip.String()), nil
+}
+
+// IpAccessRequest2CurrentGeo is an implementation to obtain a current geolocation from the request information
+func (impl *SupermanDetectorImpl) IpAccessRequest2CurrentGeo(request *supermandetector.IpAccessRequestV2) (*supermandetector.CurrentGeo, error) {
+	ip := net.ParseIP(string(request.Ip_address))
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidIpAddress, request.Ip_address)
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		// only the corporate override table may locate a private address, e.g. of an office or a vpn
+		if impl.overrides != nil {
+			geo, err := GeoResolverChain{impl.overrides}.Resolve(ip)
+			if err == nil {
+				return geo, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrGeoUnresolvable, ip)
+	}
+
+	return impl.geo.Resolve(ip)
+}
+
+// GenerateIpAccessRecord is an implementation to generate a registerable struct as IpAccessRecord from the current geolocation and the request information
+func (impl *SupermanDetectorImpl) GenerateIpAccessRecord(request *supermandetector.IpAccessRequestV2, currentGeo *supermandetector.CurrentGeo) *supermandetector.IpAccessRecord {
+	record := supermandetector.NewIpAccessRecord(&supermandetector.IpAccessRecord{
+		Username:     request.Username,
+		Timestamp_ms: request.Timestamp_ms,
+		Event_uuid:   request.Event_uuid,
+		Ip_address:   request.Ip_address,
+		Lat:          currentGeo.Lat,
+		Lon:          currentGeo.Lon,
+		Radius:       currentGeo.Radius,
+		Provider:     currentGeo.Provider,
+		Label:        currentGeo.Label,
+		CountryCode:  currentGeo.CountryCode,
+		Subdivision:  currentGeo.Subdivision,
+		City:         currentGeo.City,
+		TimeZone:     currentGeo.TimeZone,
+	})
+	ip := net.ParseIP(string(request.Ip_address))
+	if impl.asn != nil {
+		info := impl.asn.Lookup(ip)
+		record.Asn, record.AsOrganization, record.ConnectionType = info.ASN, info.Organization, info.ConnectionType
+	}
+	if impl.network != nil {
+		flags := impl.network.Classify(ip, record.Asn)
+		record.IsTor, record.IsAnonymizer, record.IsHosting = flags.Tor, flags.Anonymizer, flags.Hosting
+	}
+
+	return record
+}
+
+// RegisterIpAccessRecord is an implementation to register ip access to the store as a record
+func (impl *SupermanDetectorImpl) RegisterIpAccessRecord(ipRecord *supermandetector.IpAccessRecord) error {
+	return impl.store.RegisterIpAccessRecord(ipRecord)
+}
+
+// GetPrecedingIpAccess is an implementation to get a nearest preceding ip access of the same user from current ip access
 func (impl *SupermanDetectorImpl) GetPrecedingIpAccess(ipRecord *supermandetector.IpAccessRecord) (*supermandetector.IpAccess, error) {
-	stmt, err := impl.ipaccessdb.Prepare("select ip_address, lat, lon, radius, unix_timestamp from ipaccess where unix_timestamp < ? order by unix_timestamp limit 1")
+	preceding, err := impl.store.GetPrecedingIpAccessRecord(ipRecord.Username, ipRecord.Timestamp_ms, ipRecord.Event_uuid)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-
-	var ip_address, lat, lon, radius, unix_timestamp string
-	err = stmt.QueryRow(ipRecord.Unix_timestamp).Scan(&ip_address, &lat, &lon, &radius, &unix_timestamp)
-	if err == sql.ErrNoRows {
+	if preceding == nil {
 		log.Printf("No PrecedingIpAccess\n")
 		return nil, nil
-	} else if err != nil {
-		return nil, err
 	}
 
-	destination := haversine.Coord{Lat: float64(ipRecord.Lat), Lon: float64(ipRecord.Lon)}
-	originLat, _ := strconv.ParseFloat(lat, 64)
-	originLon, _ := strconv.ParseFloat(lon, 64)
-	originRadius, _ := strconv.Atoi(radius)
-	originUnixTimestamp, _ := strconv.Atoi(unix_timestamp)
-	origin := haversine.Coord{Lat: originLat, Lon: originLon}
-	originTime, _ := strconv.Atoi(unix_timestamp)
-	time := (int(ipRecord.Unix_timestamp) - originTime) / 3600
-	speed := impl.CalculateSpeed(origin, destination, time)
-
-	return supermandetector.NewIpAccess(&supermandetector.IpAccess{
-		Ip:        supermandetector.IPAddress(ip_address),
-		Speed:     int32(speed),
-		Lat:       originLat,
-		Lon:       originLon,
-		Radius:    int32(originRadius),
-		Timestamp: int32(originUnixTimestamp),
-	}), nil
+	return impl.GenerateIpAccess(preceding, preceding, ipRecord), nil
 }
 
-// GetSubsequentIpAccess is an implementation to get a nearest subsequent ip access from current ip access
+// GetSubsequentIpAccess is an implementation to get a nearest subsequent ip access of the same user from current ip access
 func (impl *SupermanDetectorImpl) GetSubsequentIpAccess(ipRecord *supermandetector.IpAccessRecord) (*supermandetector.IpAccess, error) {
-	stmt, err := impl.ipaccessdb.Prepare("select ip_address, lat, lon, radius, unix_timestamp from ipaccess where unix_timestamp > ? order by unix_timestamp limit 1")
+	subsequent, err := impl.store.GetSubsequentIpAccessRecord(ipRecord.Username, ipRecord.Timestamp_ms, ipRecord.Event_uuid)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-
-	var ip_address, lat, lon, radius, unix_timestamp string
-	err = stmt.QueryRow(ipRecord.Unix_timestamp).Scan(&ip_address, &lat, &lon, &radius, &unix_timestamp)
-	if err == sql.ErrNoRows {
+	if subsequent == nil {
 		log.Printf("No SubsequentIpAccess\n")
 		return nil, nil
-	} else if err != nil {
-		return nil, err
 	}
 
-	origin := haversine.Coord{Lat: float64(ipRecord.Lat), Lon: float64(ipRecord.Lon)}
-	destinationLat, _ := strconv.ParseFloat(lat, 64)
-	destinationLon, _ := strconv.ParseFloat(lon, 64)
-	destinationRadius, _ := strconv.Atoi(radius)
-	destinationUnixTimestamp, _ := strconv.Atoi(unix_timestamp)
-	destination := haversine.Coord{Lat: destinationLat, Lon: destinationLon}
-	destinationTime, _ := strconv.Atoi(unix_timestamp)
-	time := (destinationTime - int(ipRecord.Unix_timestamp)) / 3600
-	speed := impl.CalculateSpeed(origin, destination, time)
-
-	return supermandetector.NewIpAccess(&supermandetector.IpAccess{
-		Ip:        supermandetector.IPAddress(ip_address),
-		Speed:     int32(speed),
-		Lat:       destinationLat,
-		Lon:       destinationLon,
-		Radius:    int32(destinationRadius),
-		Timestamp: int32(destinationUnixTimestamp),
-	}), nil
+	return impl.GenerateIpAccess(subsequent, ipRecord, subsequent), nil
 }
 
-// CalculateSpeed is an implementation to calculate speed from the latitude and longitude of origin and destination with the time
-func (impl *SupermanDetectorImpl) CalculateSpeed(origin haversine.Coord, destination haversine.Coord, time int) int {
+// GenerateIpAccess is an implementation to generate an IpAccess of the neighbour record with the travel speed from origin to destination
+func (impl *SupermanDetectorImpl) GenerateIpAccess(neighbour *supermandetector.IpAccessRecord, origin *supermandetector.IpAccessRecord, destination *supermandetector.IpAccessRecord) *supermandetector.IpAccess {
+	ipAccess := supermandetector.NewIpAccess(&supermandetector.IpAccess{
+		Ip:             neighbour.Ip_address,
+		Lat:            neighbour.Lat,
+		Lon:            neighbour.Lon,
+		Radius:         neighbour.Radius,
+		Timestamp:      legacyTimestamp(neighbour.Timestamp_ms),
+		TimestampMs:    neighbour.Timestamp_ms,
+		Asn:            neighbour.Asn,
+		AsOrganization: neighbour.AsOrganization,
+		ConnectionType: neighbour.ConnectionType,
+		CountryCode:    neighbour.CountryCode,
+		Subdivision:    neighbour.Subdivision,
+		City:           neighbour.City,
+		TimeZone:       neighbour.TimeZone,
+	})
+
+	milliseconds := destination.Timestamp_ms - origin.Timestamp_ms
+	if milliseconds == 0 {
+		// the speed is undefined when both accesses happened at the same time
+		ipAccess.Simultaneous = new(bool)
+		*ipAccess.Simultaneous = true
+		return ipAccess
+	}
+
+	originCoord := haversine.Coord{Lat: origin.Lat, Lon: origin.Lon}
+	destinationCoord := haversine.Coord{Lat: destination.Lat, Lon: destination.Lon}
+	minSpeed, maxSpeed := impl.CalculateSpeedBounds(originCoord, origin.Radius, destinationCoord, destination.Radius, milliseconds)
+	ipAccess.Speed = clampSpeed(impl.CalculateSpeed(originCoord, destinationCoord, milliseconds))
+	ipAccess.MinSpeed = clampSpeed(minSpeed)
+	ipAccess.MaxSpeed = clampSpeed(maxSpeed)
+
+	return ipAccess
+}
+
+// CalculateSpeed is an implementation to calculate speed in mph from the latitude and longitude of origin and destination with the elapsed milliseconds
+func (impl *SupermanDetectorImpl) CalculateSpeed(origin haversine.Coord, destination haversine.Coord, milliseconds int64) float64 {
 	mi, _ := haversine.Distance(origin, destination)
-	return int(mi / float64(time))
+	return mi / hours(milliseconds)
+}
+
+// CalculateDistanceBounds is an implementation to calculate the minimum and maximum possible distance in miles between origin and destination with their accuracy radius in kilometers
+func (impl *SupermanDetectorImpl) CalculateDistanceBounds(origin haversine.Coord, originRadius int32, destination haversine.Coord, destinationRadius int32) (float64, float64) {
+	mi, _ := haversine.Distance(origin, destination)
+	radius := float64(originRadius+destinationRadius) * milesPerKilometer
+	return math.Max(0, mi-radius), mi + radius
+}
+
+// CalculateSpeedBounds is an implementation to calculate the minimum and maximum possible speed in mph from origin and destination with their accuracy radius and the elapsed milliseconds
+func (impl *SupermanDetectorImpl) CalculateSpeedBounds(origin haversine.Coord, originRadius int32, destination haversine.Coord, destinationRadius int32, milliseconds int64) (float64, float64) {
+	minMi, maxMi := impl.CalculateDistanceBounds(origin, originRadius, destination, destinationRadius)
+	return minMi / hours(milliseconds), maxMi / hours(milliseconds)
+}
+
+// clampSpeed truncates the speed in mph to fit in the response, a few milliseconds apart make it larger than an int32
+func clampSpeed(speed float64) int32 {
+	if speed >= math.MaxInt32 {
+		return math.MaxInt32
+	}
+
+	return int32(speed)
 }
 
-// PostIpAccessRequest is an implementation for the api logic
+// legacyTimestamp converts the timestamp in milliseconds into seconds for the legacy clients, clamped to fit in an int32 after 2038-01-19
+func legacyTimestamp(timestampMs int64) int32 {
+	seconds := timestampMs / 1000
+	if seconds > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if seconds < math.MinInt32 {
+		return math.MinInt32
+	}
+
+	return int32(seconds)
+}
+
+// IsTravelSuspicious is an implementation to judge whether the travel between the current ip access and its neighbour ip access is impossible
+func (impl *SupermanDetectorImpl) IsTravelSuspicious(ipRecord *supermandetector.IpAccessRecord, ipAccess *supermandetector.IpAccess) bool {
+	if impl.IsSameASNTrusted(ipRecord, ipAccess) {
+		return false
+	}
+	recordCoord := haversine.Coord{Lat: ipRecord.Lat, Lon: ipRecord.Lon}
+	accessCoord := haversine.Coord{Lat: ipAccess.Lat, Lon: ipAccess.Lon}
+	if ipAccess.Simultaneous != nil && *ipAccess.Simultaneous {
+		// accesses at the same time are suspicious unless they can be at the same location
+		minMi, _ := impl.CalculateDistanceBounds(recordCoord, ipRecord.Radius, accessCoord, ipAccess.Radius)
+		return minMi > 0
+	}
+
+	// the speed is judged before it is truncated into the int32 of the response
+	minSpeed, _ := impl.CalculateSpeedBounds(recordCoord, ipRecord.Radius, accessCoord, ipAccess.Radius, ipRecord.Timestamp_ms-ipAccess.TimestampMs)
+	return minSpeed > impl.policies.Threshold(ipRecord.Username)
+}
+
+// IsSameASNTrusted is an implementation to judge whether the travel between the current ip access and its neighbour ip access
+// stays within the same autonomous system by a trusted connection type, e.g. a mobile carrier moving its users between its gateways
+func (impl *SupermanDetectorImpl) IsSameASNTrusted(ipRecord *supermandetector.IpAccessRecord, ipAccess *supermandetector.IpAccess) bool {
+	if ipRecord.Asn == nil || ipAccess.Asn == nil || *ipRecord.Asn != *ipAccess.Asn {
+		return false
+	}
+	for _, connectionType := range impl.trustedSameASN {
+		if connectionType == "*" || (connectionType == ipRecord.ConnectionType && connectionType == ipAccess.ConnectionType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hours converts the elapsed milliseconds into fractional hours
+func hours(milliseconds int64) float64 {
+	return math.Abs(float64(milliseconds)) / 3600000
+}
+
+// PostIpAccessRequest is an implementation for the api logic of v1, converting the request into v2
 func (impl *SupermanDetectorImpl) PostIpAccessRequest(context *rdl.ResourceContext, request *supermandetector.IpAccessRequest) (*supermandetector.IpAccessResponse, error) {
+	if request == nil {
+		errMsg := "Invalid IpAccessRequest, Error:request body is empty"
+		log.Print(errMsg)
+		return nil, NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+	err := request.Validate()
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid IpAccessRequest, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
 
-	response := supermandetector.NewIpAccessResponse()
+	return impl.PostIpAccessRequestV2(context, ConvertIpAccessRequest(request))
+}
+
+// ConvertIpAccessRequest is an implementation to convert a v1 request with the timestamp in seconds into a v2 request in milliseconds
+func ConvertIpAccessRequest(request *supermandetector.IpAccessRequest) *supermandetector.IpAccessRequestV2 {
+	return supermandetector.NewIpAccessRequestV2(&supermandetector.IpAccessRequestV2{
+		Username:     request.Username,
+		Timestamp_ms: int64(request.Unix_timestamp) * 1000,
+		Event_uuid:   request.Event_uuid,
+		Ip_address:   request.Ip_address,
+	})
+}
+
+// PostIpAccessRequestV2 is an implementation for the api logic with the timestamp in milliseconds
+func (impl *SupermanDetectorImpl) PostIpAccessRequestV2(context *rdl.ResourceContext, request *supermandetector.IpAccessRequestV2) (*supermandetector.IpAccessResponse, error) {
+	err := impl.ValidateIpAccessRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := impl.store.GetIpAccessRecord(request.Event_uuid)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get IpAccessRecord, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	if existing != nil {
+		return impl.ReplayIpAccessRequest(request, existing)
+	}
 
 	currentGeo, err := impl.IpAccessRequest2CurrentGeo(request)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to get city from ip, Error:%v", err)
-		log.Printf(string(errMsg))
-		return nil, &rdl.ResourceError{Code: 200, Message: errMsg}
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeGeoUnavailable)
 	}
-	response.CurrentGeo = currentGeo
 
-	record := impl.GenerateIpAccessRecord(request, response.CurrentGeo)
+	record := impl.GenerateIpAccessRecord(request, currentGeo)
+	record.Principal = principalOf(context)
 	err = impl.RegisterIpAccessRecord(record)
+	if errors.Is(err, ErrDuplicateEvent) {
+		// a concurrent request with the same event uuid has registered it first
+		duplicateErr := err
+		existing, err = impl.store.GetIpAccessRecord(request.Event_uuid)
+		if err == nil && existing != nil {
+			return impl.ReplayIpAccessRequest(request, existing)
+		}
+		if err == nil {
+			// it is gone again, e.g. erased meanwhile, so the request is not registered either
+			err = duplicateErr
+		}
+	}
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to register IpAccessRecord, Error:%v", err)
-		log.Printf(string(errMsg))
-		return nil, &rdl.ResourceError{Code: 200, Message: errMsg}
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	return impl.GenerateIpAccessResponse(record)
+}
+
+// PostIpAccessBatchRequest is an implementation for the api logic to register the requests in one transaction.
+// The verdicts are judged after all of them are registered, so that the requests out of order in the batch are compared with each other.
+func (impl *SupermanDetectorImpl) PostIpAccessBatchRequest(context *rdl.ResourceContext, batch *supermandetector.IpAccessBatchRequest) (*supermandetector.IpAccessBatchResponse, error) {
+	if batch == nil || len(batch.Requests) == 0 {
+		errMsg := "Invalid IpAccessBatchRequest, Error:no request in the batch"
+		log.Print(errMsg)
+		return nil, NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+	if len(batch.Requests) > maxBatchSize {
+		errMsg := fmt.Sprintf("Invalid IpAccessBatchRequest, Error:%d requests exceed the limit of %d", len(batch.Requests), maxBatchSize)
+		log.Print(errMsg)
+		return nil, NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+
+	results := make([]*supermandetector.IpAccessBatchResult, len(batch.Requests))
+	// records are the ones to respond with per request, either newly registered or already registered
+	records := make([]*supermandetector.IpAccessRecord, len(batch.Requests))
+	registering := []*supermandetector.IpAccessRecord{}
+	pending := map[string]*supermandetector.IpAccessRecord{}
+	for i, request := range batch.Requests {
+		results[i] = supermandetector.NewIpAccessBatchResult()
+		if request != nil {
+			results[i].Event_uuid = request.Event_uuid
+		}
+		err := impl.ValidateIpAccessRequest(request)
+		if err != nil {
+			results[i].Error = &err.(*ServiceError).ServiceError
+			continue
+		}
+
+		existing := pending[request.Event_uuid]
+		if existing == nil {
+			existing, err = impl.store.GetIpAccessRecord(request.Event_uuid)
+			if err != nil {
+				errMsg := fmt.Sprintf("Failed to get IpAccessRecord, Error:%v", err)
+				log.Print(errMsg)
+				return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+			}
+		}
+		if existing != nil {
+			err = impl.CheckSameIpAccess(request, existing)
+			if err != nil {
+				results[i].Error = &err.(*ServiceError).ServiceError
+				continue
+			}
+			records[i] = existing
+			continue
+		}
+
+		currentGeo, err := impl.IpAccessRequest2CurrentGeo(request)
+		if err != nil {
+			errMsg := fmt.Sprintf("Failed to get city from ip, Error:%v", err)
+			log.Print(errMsg)
+			results[i].Error = &NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeGeoUnavailable).ServiceError
+			continue
+		}
+		record := impl.GenerateIpAccessRecord(request, currentGeo)
+		record.Principal = principalOf(context)
+		records[i] = record
+		registering = append(registering, record)
+		pending[record.Event_uuid] = record
+	}
+
+	err := impl.store.RegisterIpAccessRecords(registering)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to register IpAccessRecords, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	for i, record := range records {
+		if record == nil {
+			continue
+		}
+		response, err := impl.GenerateIpAccessResponse(record)
+		if err != nil {
+			results[i].Error = &err.(*ServiceError).ServiceError
+			continue
+		}
+		results[i].Response = response
+	}
+
+	return supermandetector.NewIpAccessBatchResponse(&supermandetector.IpAccessBatchResponse{
+		Results: results,
+	}), nil
+}
+
+// EvaluateIpAccessRequest is an implementation for the api logic to judge the request against the registered ip accesses without registering it
+func (impl *SupermanDetectorImpl) EvaluateIpAccessRequest(context *rdl.ResourceContext, request *supermandetector.IpAccessRequestV2) (*supermandetector.IpAccessResponse, error) {
+	err := impl.ValidateIpAccessRequest(request)
+	if err != nil {
+		return nil, err
 	}
 
+	currentGeo, err := impl.IpAccessRequest2CurrentGeo(request)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get city from ip, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeGeoUnavailable)
+	}
+
+	return impl.GenerateIpAccessResponse(impl.GenerateIpAccessRecord(request, currentGeo))
+}
+
+// ValidateIpAccessRequest is an implementation to validate the request and to normalise its ip address in place
+func (impl *SupermanDetectorImpl) ValidateIpAccessRequest(request *supermandetector.IpAccessRequestV2) error {
+	if request == nil {
+		errMsg := "Invalid IpAccessRequest, Error:request body is empty"
+		log.Print(errMsg)
+		return NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+	err := request.Validate()
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid IpAccessRequest, Error:%v", err)
+		log.Print(errMsg)
+		return NewServiceError(err, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+
+	request.Ip_address, err = NormalizeIpAddress(request.Ip_address)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid IpAccessRequest, Error:%v", err)
+		log.Print(errMsg)
+		return NewServiceError(err, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+
+	return nil
+}
+
+// ReplayIpAccessRequest is an implementation to answer a retried request with the response recomputed from the record registered first,
+// or to reject the request reusing the event uuid for a different access
+func (impl *SupermanDetectorImpl) ReplayIpAccessRequest(request *supermandetector.IpAccessRequestV2, existing *supermandetector.IpAccessRecord) (*supermandetector.IpAccessResponse, error) {
+	err := impl.CheckSameIpAccess(request, existing)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Replaying IpAccessRequest of event_uuid %s\n", request.Event_uuid)
+
+	return impl.GenerateIpAccessResponse(existing)
+}
+
+// CheckSameIpAccess is an implementation to reject the request reusing the event uuid of the registered record for a different access
+func (impl *SupermanDetectorImpl) CheckSameIpAccess(request *supermandetector.IpAccessRequestV2, existing *supermandetector.IpAccessRecord) error {
+	if request.Username == existing.Username && request.Timestamp_ms == existing.Timestamp_ms && request.Ip_address == existing.Ip_address {
+		return nil
+	}
+
+	err := fmt.Errorf("event_uuid %s: %w", request.Event_uuid, ErrEventConflict)
+	errMsg := fmt.Sprintf("Failed to register IpAccessRecord, Error:%v", err)
+	log.Print(errMsg)
+	return NewServiceError(err, errMsg, http.StatusConflict, ErrorCodeDuplicateEvent)
+}
+
+// IsNetworkSuspicious is an implementation to judge whether the network of the record makes the access suspicious,
+// nil if no kind of the networks is configured as suspicious or the network of the record is not classified
+func (impl *SupermanDetectorImpl) IsNetworkSuspicious(record *supermandetector.IpAccessRecord) *bool {
+	flags := map[string]*bool{
+		NetworkTor:        record.IsTor,
+		NetworkAnonymizer: record.IsAnonymizer,
+		NetworkHosting:    record.IsHosting,
+	}
+
+	var suspicious *bool
+	for _, kind := range impl.suspiciousNetworks {
+		if flags[kind] == nil {
+			continue
+		}
+		if suspicious == nil {
+			suspicious = new(bool)
+		}
+		*suspicious = *suspicious || *flags[kind]
+	}
+
+	return suspicious
+}
+
+// GenerateIpAccessResponse is an implementation to generate the response of the registered record with its neighbour ip accesses and the verdicts
+func (impl *SupermanDetectorImpl) GenerateIpAccessResponse(record *supermandetector.IpAccessRecord) (*supermandetector.IpAccessResponse, error) {
+	var err error
+
+	response := supermandetector.NewIpAccessResponse()
+	response.CurrentGeo = supermandetector.NewCurrentGeo(&supermandetector.CurrentGeo{
+		Lat:            record.Lat,
+		Lon:            record.Lon,
+		Radius:         record.Radius,
+		Provider:       record.Provider,
+		Label:          record.Label,
+		Asn:            record.Asn,
+		AsOrganization: record.AsOrganization,
+		ConnectionType: record.ConnectionType,
+		CountryCode:    record.CountryCode,
+		Subdivision:    record.Subdivision,
+		City:           record.City,
+		TimeZone:       record.TimeZone,
+	})
+	response.IsTor, response.IsAnonymizer, response.IsHosting = record.IsTor, record.IsAnonymizer, record.IsHosting
+	response.NetworkSuspicious = impl.IsNetworkSuspicious(record)
+
 	response.PrecedingIpAccess, err = impl.GetPrecedingIpAccess(record)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed get PrecedingIpAccess, Error:%v", err)
-		return nil, &rdl.ResourceError{Code: 200, Message: errMsg}
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
 	}
 	if response.PrecedingIpAccess != nil {
 		response.TravelToCurrentGeoSuspicious = new(bool)
-		*response.TravelToCurrentGeoSuspicious = (response.PrecedingIpAccess.Speed > impl.speedThreshold)
+		*response.TravelToCurrentGeoSuspicious = impl.IsTravelSuspicious(record, response.PrecedingIpAccess)
 		log.Printf("PrecedingIpAccess: %v\n", *response.PrecedingIpAccess)
+		if *response.TravelToCurrentGeoSuspicious {
+			log.Printf("Suspicious travel of %s: %s -> %s\n", record.Username, PlaceName(response.PrecedingIpAccess.City, response.PrecedingIpAccess.CountryCode, response.PrecedingIpAccess.Lat, response.PrecedingIpAccess.Lon), PlaceName(record.City, record.CountryCode, record.Lat, record.Lon))
+		}
 	}
 
 	response.SubsequentIpAccess, err = impl.GetSubsequentIpAccess(record)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed get SubsequentIpAccess, Error:%v", err)
-		return nil, &rdl.ResourceError{Code: 200, Message: errMsg}
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
 	}
 	if response.SubsequentIpAccess != nil {
 		response.TravelFromCurrentGeoSuspicious = new(bool)
-		*response.TravelFromCurrentGeoSuspicious = (response.SubsequentIpAccess.Speed > impl.speedThreshold)
+		*response.TravelFromCurrentGeoSuspicious = impl.IsTravelSuspicious(record, response.SubsequentIpAccess)
 		log.Printf("SubsequentIpAccess: %v\n", *response.SubsequentIpAccess)
+		if *response.TravelFromCurrentGeoSuspicious {
+			log.Printf("Suspicious travel of %s: %s -> %s\n", record.Username, PlaceName(record.City, record.CountryCode, record.Lat, record.Lon), PlaceName(response.SubsequentIpAccess.City, response.SubsequentIpAccess.CountryCode, response.SubsequentIpAccess.Lat, response.SubsequentIpAccess.Lon))
+		}
 	}
 
 	return response, nil
 }
 
-//
-// the following is to support TLS-based authentication, and self-authorization that just logs what if *could* enforce.
-//
+// PlaceName is an implementation to name a place for people, e.g. "Los Angeles, US", or by its coordinates when its names are unknown
+func PlaceName(city string, countryCode string, lat float64, lon float64) string {
+	switch {
+	case city != "" && countryCode != "":
+		return city + ", " + countryCode
+	case city != "":
+		return city
+	case countryCode != "":
+		return countryCode
+	}
+
+	return fmt.Sprintf("%.4f,%.4f", lat, lon)
+}
+
+// GetIpAccessEvent is an implementation for the api logic to get the registered record of the event uuid with its verdict.
+// The verdict is judged again with the records registered at the moment, so that the events arrived late are reflected.
+func (impl *SupermanDetectorImpl) GetIpAccessEvent(context *rdl.ResourceContext, uuid string) (*supermandetector.IpAccessEvent, error) {
+	record, err := impl.store.GetIpAccessRecord(uuid)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get IpAccessRecord, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	if record == nil {
+		errMsg := fmt.Sprintf("No IpAccessRecord for event_uuid: %s", uuid)
+		log.Print(errMsg)
+		return nil, NewServiceError(nil, errMsg, http.StatusNotFound, ErrorCodeEventNotFound)
+	}
+
+	verdict, err := impl.GenerateIpAccessResponse(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return supermandetector.NewIpAccessEvent(&supermandetector.IpAccessEvent{
+		Record:  record,
+		Verdict: verdict,
+	}), nil
+}
+
+// GetUserIpAccessTimeline is an implementation for the api logic to list the records of the user in a time window page by page,
+// each annotated with the travel from its preceding record and to its subsequent record.
+// The window is from the from timestamp inclusive to the to timestamp exclusive in milliseconds.
+func (impl *SupermanDetectorImpl) GetUserIpAccessTimeline(context *rdl.ResourceContext, username string, from *int64, to *int64, limit int32, next string) (*supermandetector.IpAccessTimeline, error) {
+	if limit < 1 || limit > maxTimelineLimit {
+		errMsg := fmt.Sprintf("Invalid IpAccessTimeline request, Error:limit %d is out of range from 1 to %d", limit, maxTimelineLimit)
+		log.Print(errMsg)
+		return nil, NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+
+	startMs, eventUuid := int64(math.MinInt64), ""
+	if from != nil {
+		startMs = *from
+	}
+	endMs := int64(math.MaxInt64)
+	if to != nil {
+		endMs = *to
+	}
+	if endMs < startMs {
+		errMsg := fmt.Sprintf("Invalid IpAccessTimeline request, Error:to %d is before from %d", endMs, startMs)
+		log.Print(errMsg)
+		return nil, NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+	if next != "" {
+		var err error
+		startMs, eventUuid, err = DecodeTimelineCursor(next)
+		if err != nil {
+			errMsg := fmt.Sprintf("Invalid IpAccessTimeline request, Error:%v", err)
+			log.Print(errMsg)
+			return nil, NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+		}
+	}
+
+	// one more record than the limit tells whether there is a next page, and is the subsequent record of the last one
+	records, err := impl.store.ListIpAccessRecordsBetween(username, startMs, eventUuid, endMs, int(limit)+1)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to list IpAccessRecords, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
 
+	timeline := supermandetector.NewIpAccessTimeline(&supermandetector.IpAccessTimeline{
+		Username: username,
+		Accesses: []*supermandetector.IpAccessTimelineEntry{},
+	})
+	if len(records) == 0 {
+		return timeline, nil
+	}
+
+	var subsequent *supermandetector.IpAccessRecord
+	if len(records) > int(limit) {
+		subsequent = records[limit]
+		records = records[:limit]
+		last := records[len(records)-1]
+		timeline.Next = EncodeTimelineCursor(last.Timestamp_ms, last.Event_uuid)
+	} else {
+		last := records[len(records)-1]
+		subsequent, err = impl.store.GetSubsequentIpAccessRecord(username, last.Timestamp_ms, last.Event_uuid)
+		if err != nil {
+			errMsg := fmt.Sprintf("Failed get SubsequentIpAccess, Error:%v", err)
+			log.Print(errMsg)
+			return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+		}
+	}
+	preceding, err := impl.store.GetPrecedingIpAccessRecord(username, records[0].Timestamp_ms, records[0].Event_uuid)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed get PrecedingIpAccess, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	for i, record := range records {
+		if i > 0 {
+			preceding = records[i-1]
+		}
+		next := subsequent
+		if i < len(records)-1 {
+			next = records[i+1]
+		}
+		timeline.Accesses = append(timeline.Accesses, impl.GenerateIpAccessTimelineEntry(record, preceding, next))
+	}
+
+	return timeline, nil
+}
+
+// GenerateIpAccessTimelineEntry is an implementation to annotate a record with the travel from its preceding record and to its subsequent record
+func (impl *SupermanDetectorImpl) GenerateIpAccessTimelineEntry(record *supermandetector.IpAccessRecord, preceding *supermandetector.IpAccessRecord, subsequent *supermandetector.IpAccessRecord) *supermandetector.IpAccessTimelineEntry {
+	entry := supermandetector.NewIpAccessTimelineEntry(&supermandetector.IpAccessTimelineEntry{
+		Record: record,
+	})
+	if preceding != nil {
+		entry.PrecedingIpAccess = impl.GenerateIpAccess(preceding, preceding, record)
+		entry.TravelToCurrentGeoSuspicious = new(bool)
+		*entry.TravelToCurrentGeoSuspicious = impl.IsTravelSuspicious(record, entry.PrecedingIpAccess)
+	}
+	if subsequent != nil {
+		entry.SubsequentIpAccess = impl.GenerateIpAccess(subsequent, record, subsequent)
+		entry.TravelFromCurrentGeoSuspicious = new(bool)
+		*entry.TravelFromCurrentGeoSuspicious = impl.IsTravelSuspicious(record, entry.SubsequentIpAccess)
+	}
+
+	return entry
+}
+
+// EncodeTimelineCursor is an implementation to encode the position of the last record of a page into an opaque cursor of the next page
+func EncodeTimelineCursor(timestampMs int64, eventUuid string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(timestampMs, 10) + "/" + eventUuid))
+}
+
+// DecodeTimelineCursor is an implementation to decode the cursor of a page into the position of the last record of the previous page
+func DecodeTimelineCursor(cursor string) (int64, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", fmt.Errorf("malformed cursor %q", cursor)
+	}
+	parts := strings.SplitN(string(b), "/", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("malformed cursor %q", cursor)
+	}
+	timestampMs, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("malformed cursor %q", cursor)
+	}
+
+	return timestampMs, parts[1], nil
+}
+
+// GetUserDataExport is an implementation for the api logic to export all data stored for the user in JSON or CSV.
+// The export is recorded in the audit log.
+func (impl *SupermanDetectorImpl) GetUserDataExport(context *rdl.ResourceContext, username string, format string) (*supermandetector.UserDataExport, error) {
+	if format != "json" && format != "csv" {
+		errMsg := fmt.Sprintf("Invalid UserDataExport request, Error:unknown format %q", format)
+		log.Print(errMsg)
+		return nil, NewServiceError(nil, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+
+	records, err := impl.store.ListIpAccessRecords(username)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to list IpAccessRecords, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	policy, groups := impl.policies.UserPolicy(username)
+
+	err = impl.audit.Record(AuditActionExport, principalOf(context), username, len(records))
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to write audit log, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	return supermandetector.NewUserDataExport(&supermandetector.UserDataExport{
+		Username: username,
+		Records:  records,
+		Policy:   policy,
+		Groups:   groups,
+	}), nil
+}
+
+// DeleteUserData is an implementation for the api logic to erase all data stored for the user, the records and the speed policies.
+// The erasure is recorded in the audit log before anything is erased, and again once done, so that no erasure goes unaudited.
+// It can be retried as it erases nothing more once done.
+func (impl *SupermanDetectorImpl) DeleteUserData(context *rdl.ResourceContext, username string) (*supermandetector.UserDataErasure, error) {
+	// the records going to be erased are counted for the audit log before anything is erased
+	records, err := impl.store.ListIpAccessRecords(username)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to list IpAccessRecords, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	err = impl.audit.Record(AuditActionEraseRequest, principalOf(context), username, len(records))
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to write audit log, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	n, err := impl.store.DeleteIpAccessRecords(username)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to delete IpAccessRecords, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	policy, groups, err := impl.policies.EraseUser(username)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to delete SpeedPolicy, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	err = impl.audit.Record(AuditActionErase, principalOf(context), username, n)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to write audit log, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	log.Printf("Erased %d IpAccessRecords of user %s\n", n, username)
+
+	return supermandetector.NewUserDataErasure(&supermandetector.UserDataErasure{
+		Username: username,
+		Records:  int32(n),
+		Policy:   policy,
+		Groups:   groups,
+	}), nil
+}
+
+// principalOf returns the name of the principal authenticated for the request, or an empty string for an anonymous request
+func principalOf(context *rdl.ResourceContext) string {
+	if context != nil && context.Principal != nil {
+		return context.Principal.GetYRN()
+	}
+
+	return ""
+}
+
+// GetServiceStatus is an implementation to get the status of the service, e.g. the build of the GeoLite2 City database loaded
+func (impl *SupermanDetectorImpl) GetServiceStatus(context *rdl.ResourceContext) (*supermandetector.ServiceStatus, error) {
+	status := supermandetector.NewServiceStatus(&supermandetector.ServiceStatus{
+		GeoProviders: impl.geo.Providers(),
+	})
+	if impl.geodb != nil {
+		status.Geodb = impl.geodb.Status()
+	}
+	if impl.overrides != nil {
+		status.Overrides = impl.overrides.Status()
+	}
+	if impl.network != nil && impl.network.anonymousIP != nil {
+		status.AnonymousIpDb = impl.network.anonymousIP.Status()
+	}
+	if impl.asn != nil && impl.asn.asn != nil {
+		status.AsnDb = impl.asn.asn.Status()
+	}
+	if impl.asn != nil && impl.asn.connectionType != nil {
+		status.ConnectionTypeDb = impl.asn.connectionType.Status()
+	}
+
+	return status, nil
+}
+
+// GetRetentionStatus is an implementation to get the retention policy and the metrics of the purge
+func (impl *SupermanDetectorImpl) GetRetentionStatus(context *rdl.ResourceContext) (*supermandetector.RetentionStatus, error) {
+	if impl.purger == nil {
+		return NewRetentionStatus(impl.retention), nil
+	}
+
+	return impl.purger.RetentionStatus(), nil
+}
+
+// GetSpeedPolicies is an implementation to list the default speed policy and its overrides
+func (impl *SupermanDetectorImpl) GetSpeedPolicies(context *rdl.ResourceContext) (*supermandetector.SpeedPolicies, error) {
+	return impl.policies.SpeedPolicies(), nil
+}
+
+// PutUserSpeedPolicy is an implementation to override the speed policy of a user
+func (impl *SupermanDetectorImpl) PutUserSpeedPolicy(context *rdl.ResourceContext, username string, policy *supermandetector.SpeedPolicy) (*supermandetector.SpeedPolicy, error) {
+	err := ValidateSpeedPolicy(policy)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid SpeedPolicy, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+
+	err = impl.policies.PutUserPolicy(username, policy)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to put SpeedPolicy, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	return policy, nil
+}
+
+// DeleteUserSpeedPolicy is an implementation to delete the speed policy override of a user
+func (impl *SupermanDetectorImpl) DeleteUserSpeedPolicy(context *rdl.ResourceContext, username string) error {
+	ok, err := impl.policies.DeleteUserPolicy(username)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to delete SpeedPolicy, Error:%v", err)
+		log.Print(errMsg)
+		return NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	if !ok {
+		return NewServiceError(nil, fmt.Sprintf("No SpeedPolicy for user: %s", username), http.StatusNotFound, ErrorCodePolicyNotFound)
+	}
+
+	return nil
+}
+
+// PutSpeedPolicyGroup is an implementation to create or replace a user group with its speed policy
+func (impl *SupermanDetectorImpl) PutSpeedPolicyGroup(context *rdl.ResourceContext, name string, group *supermandetector.SpeedPolicyGroup) (*supermandetector.SpeedPolicyGroup, error) {
+	group.Name = name
+	err := ValidateSpeedPolicy(group.Policy)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid SpeedPolicyGroup, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusBadRequest, ErrorCodeInvalidRequest)
+	}
+
+	err = impl.policies.PutGroup(group)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to put SpeedPolicyGroup, Error:%v", err)
+		log.Print(errMsg)
+		return nil, NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+
+	return group, nil
+}
+
+// DeleteSpeedPolicyGroup is an implementation to delete a user group with its speed policy
+func (impl *SupermanDetectorImpl) DeleteSpeedPolicyGroup(context *rdl.ResourceContext, name string) error {
+	ok, err := impl.policies.DeleteGroup(name)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to delete SpeedPolicyGroup, Error:%v", err)
+		log.Print(errMsg)
+		return NewServiceError(err, errMsg, http.StatusServiceUnavailable, ErrorCodeStorageUnavailable)
+	}
+	if !ok {
+		return NewServiceError(nil, fmt.Sprintf("No SpeedPolicyGroup: %s", name), http.StatusNotFound, ErrorCodePolicyNotFound)
+	}
+
+	return nil
+}
+
+// Authorize is an implementation to authorize the administration of the speed policies and the user data to the admins of the credentials file.
+// Everyone is authorized if no credentials are configured, as the requests are accepted anonymously.
 func (impl *SupermanDetectorImpl) Authorize(action string, resource string, principal rdl.Principal) (bool, error) {
-	return true, nil
+	if !impl.authRequired {
+		return true, nil
+	}
+	if principal == nil {
+		return false, nil
+	}
+	for _, admin := range impl.admins {
+		if admin == principal.GetYRN() {
+			return true, nil
+		}
+	}
+	log.Printf("Denied %s on %s to %s\n", action, resource, principal.GetYRN())
+
+	return false, nil
 }
 
+// Authenticate is an implementation to authenticate the request by the verified client certificate when none of the authenticators does,
+// or to accept it anonymously if no credentials are configured
 func (impl *SupermanDetectorImpl) Authenticate(context *rdl.ResourceContext) bool {
-	return false
+	principal := PrincipalFromTLS(context.Request)
+	if principal == nil {
+		return !impl.authRequired
+	}
+	context.Principal = principal
+
+	return true
 }
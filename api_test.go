@@ -1,24 +1,62 @@
 package main
 
 import (
-	"database/sql"
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
-	"github.com/ardielle/ardielle-go/rdl"
 	"gitlab.com/cty3000/superman-detector/supermandetector"
 
-	_ "github.com/mattn/go-sqlite3"
-	"github.com/oschwald/geoip2-golang"
+	"github.com/umahmood/haversine"
 )
 
+// failingWriter is a writer failing every write, e.g. of a full disk
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+// racingStore is an access store on which a concurrent request has always registered the event first
+type racingStore struct {
+	AccessStore
+	existing   *supermandetector.IpAccessRecord
+	err        error
+	registered bool
+}
+
+func (store *racingStore) RegisterIpAccessRecord(ipRecord *supermandetector.IpAccessRecord) error {
+	store.registered = true
+	return ErrDuplicateEvent
+}
+
+func (store *racingStore) GetIpAccessRecord(eventUuid string) (*supermandetector.IpAccessRecord, error) {
+	if !store.registered {
+		return nil, nil
+	}
+	return store.existing, store.err
+}
+
+func newTestConfig() *Config {
+	config := NewConfig()
+	config.StorageDriver = "memory"
+	return config
+}
+
 func TestNewSupermanDetectorImpl(t *testing.T) {
 	type args struct {
 		baseUrl        string
-		ipaccessdb     *sql.DB
-		geodb          *geoip2.Reader
-		speedThreshold int32
+		store          AccessStore
+		geodb          *GeoDB
+		speedThreshold float64
 	}
 	type test struct {
 		name       string
@@ -31,11 +69,12 @@ func TestNewSupermanDetectorImpl(t *testing.T) {
 	}
 	tests := []test{
 		func() test {
-			ipaccessdb, _ := sql.Open("sqlite3", "./ipaccess.db")
-			geodb, _ := geoip2.Open("GeoLite2-City.mmdb")
+			store := NewMemoryAccessStore()
+			geodb, _ := OpenGeoDB("GeoLite2-City.mmdb")
+			policies, _ := NewPolicyStore(&supermandetector.SpeedPolicy{Threshold: 500, Unit: supermandetector.MPH}, "")
 			args := args{
 				baseUrl:        "http://0.0.0.0:80/",
-				ipaccessdb:     ipaccessdb,
+				store:          store,
 				geodb:          geodb,
 				speedThreshold: 500,
 			}
@@ -44,8 +83,8 @@ func TestNewSupermanDetectorImpl(t *testing.T) {
 				args: args,
 				checkFunc: func(gotS, wantS *SupermanDetectorImpl) error {
 					if !reflect.DeepEqual(gotS.baseUrl, wantS.baseUrl) ||
-						!reflect.DeepEqual(gotS.speedThreshold, wantS.speedThreshold) ||
-						reflect.TypeOf(gotS.ipaccessdb) != reflect.TypeOf(wantS.ipaccessdb) ||
+						!reflect.DeepEqual(gotS.policies.Threshold("bob"), wantS.policies.Threshold("bob")) ||
+						reflect.TypeOf(gotS.store) != reflect.TypeOf(wantS.store) ||
 						reflect.TypeOf(gotS.geodb) != reflect.TypeOf(wantS.geodb) {
 
 						return fmt.Errorf("got: %+v, want: %+v", gotS, wantS)
@@ -53,10 +92,10 @@ func TestNewSupermanDetectorImpl(t *testing.T) {
 					return nil
 				},
 				want: &SupermanDetectorImpl{
-					baseUrl:        args.baseUrl,
-					ipaccessdb:     args.ipaccessdb,
-					geodb:          args.geodb,
-					speedThreshold: args.speedThreshold,
+					baseUrl:  args.baseUrl,
+					store:    args.store,
+					geodb:    args.geodb,
+					policies: policies,
 				},
 			}
 		}(),
@@ -70,7 +109,7 @@ func TestNewSupermanDetectorImpl(t *testing.T) {
 				defer tt.afterFunc()
 			}
 
-			got, err := NewSupermanDetectorImpl(tt.args.baseUrl)
+			got, err := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
 			if tt.wantErr == nil && err != nil {
 				t.Errorf("failed to instantiate, error: %v", err)
 				return
@@ -97,9 +136,10 @@ func TestNewSupermanDetectorImpl(t *testing.T) {
 func TestIpAccessRequest2CurrentGeo(t *testing.T) {
 	type args struct {
 		baseUrl string
-		request supermandetector.IpAccessRequest
-		ipaccessdb     *sql.DB
-		geodb          *geoip2.Reader
+		locale  string
+		request supermandetector.IpAccessRequestV2
+		store   AccessStore
+		geodb   *GeoDB
 	}
 	type test struct {
 		name       string
@@ -114,11 +154,11 @@ func TestIpAccessRequest2CurrentGeo(t *testing.T) {
 		func() test {
 			args := args{
 				baseUrl: "http://0.0.0.0:80/",
-				request: supermandetector.IpAccessRequest{
-					Username:       "bob",
-					Unix_timestamp: 1514761200,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e42",
-					Ip_address:     "91.207.175.104",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
 				},
 			}
 			return test{
@@ -132,9 +172,77 @@ func TestIpAccessRequest2CurrentGeo(t *testing.T) {
 					return nil
 				},
 				want: &supermandetector.CurrentGeo{
-					Lat:    34.0549,
-					Lon:    -118.2578,
-					Radius: 200,
+					Lat:         34.0549,
+					Lon:         -118.2578,
+					Radius:      200,
+					Provider:    "maxmind",
+					CountryCode: "US",
+					Subdivision: "California",
+					City:        "Los Angeles",
+					TimeZone:    "America/Los_Angeles",
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "::ffff:91.207.175.104",
+				},
+			}
+			return test{
+				name: "Check IPv4-mapped IPv6 address",
+				args: args,
+				checkFunc: func(gotS, wantS *supermandetector.CurrentGeo) error {
+					if !reflect.DeepEqual(gotS, wantS) {
+						return fmt.Errorf("got: %+v, want: %+v", gotS, wantS)
+					}
+					return nil
+				},
+				want: &supermandetector.CurrentGeo{
+					Lat:         34.0549,
+					Lon:         -118.2578,
+					Radius:      200,
+					Provider:    "maxmind",
+					CountryCode: "US",
+					Subdivision: "California",
+					City:        "Los Angeles",
+					TimeZone:    "America/Los_Angeles",
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				locale:  "de",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
+				},
+			}
+			return test{
+				name: "Check names of the locale",
+				args: args,
+				checkFunc: func(gotS, wantS *supermandetector.CurrentGeo) error {
+					if !reflect.DeepEqual(gotS, wantS) {
+						return fmt.Errorf("got: %+v, want: %+v", gotS, wantS)
+					}
+					return nil
+				},
+				want: &supermandetector.CurrentGeo{
+					Lat:         34.0549,
+					Lon:         -118.2578,
+					Radius:      200,
+					Provider:    "maxmind",
+					CountryCode: "US",
+					Subdivision: "Kalifornien",
+					City:        "Los Angeles",
+					TimeZone:    "America/Los_Angeles",
 				},
 			}
 		}(),
@@ -148,10 +256,14 @@ func TestIpAccessRequest2CurrentGeo(t *testing.T) {
 				defer tt.afterFunc()
 			}
 
-			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl)
+			config := newTestConfig()
+			if tt.args.locale != "" {
+				config.GeoLocale = tt.args.locale
+			}
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, config)
 
-			if tt.args.ipaccessdb != nil {
-				impl.ipaccessdb = tt.args.ipaccessdb
+			if tt.args.store != nil {
+				impl.store = tt.args.store
 			}
 			if tt.args.geodb != nil {
 				impl.geodb = tt.args.geodb
@@ -181,10 +293,70 @@ func TestIpAccessRequest2CurrentGeo(t *testing.T) {
 	}
 }
 
+func TestNormalizeIpAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		ipAddress supermandetector.IPAddress
+		want      supermandetector.IPAddress
+		wantErr   bool
+	}{
+		{
+			name:      "Check IPv4 address",
+			ipAddress: "91.207.175.104",
+			want:      "91.207.175.104",
+		},
+		{
+			name:      "Check IPv6 address",
+			ipAddress: "2001:0DB8:0000:0000:0000:0000:0000:0001",
+			want:      "2001:db8::1",
+		},
+		{
+			name:      "Check IPv4-mapped IPv6 address",
+			ipAddress: "::FFFF:91.207.175.104",
+			want:      "91.207.175.104",
+		},
+		{
+			name:      "Check invalid address",
+			ipAddress: "2001:db8:::1",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeIpAddress(tt.ipAddress)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeIpAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeIpAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertIpAccessRequest(t *testing.T) {
+	request := &supermandetector.IpAccessRequest{
+		Username:       "bob",
+		Unix_timestamp: 1514761200,
+		Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e42",
+		Ip_address:     "91.207.175.104",
+	}
+	want := &supermandetector.IpAccessRequestV2{
+		Username:     "bob",
+		Timestamp_ms: 1514761200000,
+		Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+		Ip_address:   "91.207.175.104",
+	}
+	if got := ConvertIpAccessRequest(request); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
+
 func TestGenerateIpAccessRecord(t *testing.T) {
 	type args struct {
 		baseUrl    string
-		request    supermandetector.IpAccessRequest
+		request    supermandetector.IpAccessRequestV2
 		currentGeo supermandetector.CurrentGeo
 	}
 	type test struct {
@@ -200,16 +372,18 @@ func TestGenerateIpAccessRecord(t *testing.T) {
 		func() test {
 			args := args{
 				baseUrl: "http://0.0.0.0:80/",
-				request: supermandetector.IpAccessRequest{
-					Username:       "bob",
-					Unix_timestamp: 1514761200,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e42",
-					Ip_address:     "91.207.175.104",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
 				},
 				currentGeo: supermandetector.CurrentGeo{
-					Lat:    34.0549,
-					Lon:    -118.2578,
-					Radius: 200,
+					Lat:      34.0549,
+					Lon:      -118.2578,
+					Radius:   200,
+					Provider: "override",
+					Label:    "la-office",
 				},
 			}
 			return test{
@@ -223,13 +397,15 @@ func TestGenerateIpAccessRecord(t *testing.T) {
 					return nil
 				},
 				want: &supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514761200,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e42",
-					Ip_address:     "91.207.175.104",
-					Lat:            34.0549,
-					Lon:            -118.2578,
-					Radius:         200,
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+					Provider:     "override",
+					Label:        "la-office",
 				},
 			}
 		}(),
@@ -243,7 +419,7 @@ func TestGenerateIpAccessRecord(t *testing.T) {
 				defer tt.afterFunc()
 			}
 
-			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl)
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
 			got := impl.GenerateIpAccessRecord(&tt.args.request, &tt.args.currentGeo)
 
 			if tt.checkFunc != nil {
@@ -262,8 +438,9 @@ func TestGetPrecedingIpAccess(t *testing.T) {
 		baseUrl         string
 		currentRecord   *supermandetector.IpAccessRecord
 		precedingRecord *supermandetector.IpAccessRecord
-		ipaccessdb     *sql.DB
-		geodb          *geoip2.Reader
+		otherRecords    []*supermandetector.IpAccessRecord
+		store           AccessStore
+		geodb           *GeoDB
 	}
 	type test struct {
 		name       string
@@ -279,22 +456,22 @@ func TestGetPrecedingIpAccess(t *testing.T) {
 			args := args{
 				baseUrl: "http://0.0.0.0:80/",
 				currentRecord: &supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514764800,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
-					Ip_address:     "206.81.252.7",
-					Lat:            39.2293,
-					Lon:            -76.6907,
-					Radius:         10,
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
 				},
 				precedingRecord: &supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514761200,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e42",
-					Ip_address:     "91.207.175.104",
-					Lat:            34.0549,
-					Lon:            -118.2578,
-					Radius:         200,
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
 				},
 			}
 			return test{
@@ -308,12 +485,15 @@ func TestGetPrecedingIpAccess(t *testing.T) {
 					return nil
 				},
 				want: &supermandetector.IpAccess{
-					Ip:        "91.207.175.104",
-					Speed:     2311,
-					Lat:       34.0549,
-					Lon:       -118.2578,
-					Radius:    200,
-					Timestamp: 1514761200,
+					Ip:          "91.207.175.104",
+					Speed:       2311,
+					MinSpeed:    2180,
+					MaxSpeed:    2441,
+					Lat:         34.0549,
+					Lon:         -118.2578,
+					Radius:      200,
+					Timestamp:   1514761200,
+					TimestampMs: 1514761200000,
 				},
 			}
 		}(),
@@ -321,13 +501,122 @@ func TestGetPrecedingIpAccess(t *testing.T) {
 			args := args{
 				baseUrl: "http://0.0.0.0:80/",
 				currentRecord: &supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514764800,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
-					Ip_address:     "206.81.252.7",
-					Lat:            39.2293,
-					Lon:            -76.6907,
-					Radius:         10,
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
+				},
+				precedingRecord: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+				},
+				otherRecords: []*supermandetector.IpAccessRecord{
+					&supermandetector.IpAccessRecord{
+						Username:     "bob",
+						Timestamp_ms: 1514674800000,
+						Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e43",
+						Ip_address:   "24.242.71.20",
+						Lat:          30.3773,
+						Lon:          -97.71,
+						Radius:       5,
+					},
+					&supermandetector.IpAccessRecord{
+						Username:     "alice",
+						Timestamp_ms: 1514763000000,
+						Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e44",
+						Ip_address:   "24.242.71.20",
+						Lat:          30.3773,
+						Lon:          -97.71,
+						Radius:       5,
+					},
+				},
+			}
+			return test{
+				name: "Check nearest preceding ip access of the same user",
+				args: args,
+				checkFunc: func(gotS, wantS *supermandetector.IpAccess) error {
+					if !reflect.DeepEqual(gotS, wantS) {
+
+						return fmt.Errorf("got: %+v, want: %+v", gotS, wantS)
+					}
+					return nil
+				},
+				want: &supermandetector.IpAccess{
+					Ip:          "91.207.175.104",
+					Speed:       2311,
+					MinSpeed:    2180,
+					MaxSpeed:    2441,
+					Lat:         34.0549,
+					Lon:         -118.2578,
+					Radius:      200,
+					Timestamp:   1514761200,
+					TimestampMs: 1514761200000,
+				},
+			}
+		}(),
+		func() test {
+			simultaneous := true
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				currentRecord: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
+				},
+				precedingRecord: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e40",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+				},
+			}
+			return test{
+				name: "Check simultaneous preceding ip access",
+				args: args,
+				checkFunc: func(gotS, wantS *supermandetector.IpAccess) error {
+					if !reflect.DeepEqual(gotS, wantS) {
+
+						return fmt.Errorf("got: %+v, want: %+v", gotS, wantS)
+					}
+					return nil
+				},
+				want: &supermandetector.IpAccess{
+					Ip:           "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+					Timestamp:    1514764800,
+					TimestampMs:  1514764800000,
+					Simultaneous: &simultaneous,
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				currentRecord: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
 				},
 			}
 			return test{
@@ -353,10 +642,10 @@ func TestGetPrecedingIpAccess(t *testing.T) {
 				defer tt.afterFunc()
 			}
 
-			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl)
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
 
-			if tt.args.ipaccessdb != nil {
-				impl.ipaccessdb = tt.args.ipaccessdb
+			if tt.args.store != nil {
+				impl.store = tt.args.store
 			}
 			if tt.args.geodb != nil {
 				impl.geodb = tt.args.geodb
@@ -366,6 +655,11 @@ func TestGetPrecedingIpAccess(t *testing.T) {
 			if e == nil && tt.args.precedingRecord != nil {
 				e = impl.RegisterIpAccessRecord(tt.args.precedingRecord)
 			}
+			for _, r := range tt.args.otherRecords {
+				if e == nil {
+					e = impl.RegisterIpAccessRecord(r)
+				}
+			}
 
 			if tt.wantErr == nil && e != nil {
 				t.Errorf("failed to instantiate, error: %v", e)
@@ -411,8 +705,9 @@ func TestGetSubsequentIpAccess(t *testing.T) {
 		baseUrl          string
 		currentRecord    *supermandetector.IpAccessRecord
 		subsequentRecord *supermandetector.IpAccessRecord
-		ipaccessdb     *sql.DB
-		geodb          *geoip2.Reader
+		otherRecords     []*supermandetector.IpAccessRecord
+		store            AccessStore
+		geodb            *GeoDB
 	}
 	type test struct {
 		name       string
@@ -428,22 +723,22 @@ func TestGetSubsequentIpAccess(t *testing.T) {
 			args := args{
 				baseUrl: "http://0.0.0.0:80/",
 				currentRecord: &supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514764800,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
-					Ip_address:     "206.81.252.7",
-					Lat:            39.2293,
-					Lon:            -76.6907,
-					Radius:         10,
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
 				},
 				subsequentRecord: &supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514851200,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e40",
-					Ip_address:     "24.242.71.20",
-					Lat:            30.3773,
-					Lon:            -97.71,
-					Radius:         5,
+					Username:     "bob",
+					Timestamp_ms: 1514851200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e40",
+					Ip_address:   "24.242.71.20",
+					Lat:          30.3773,
+					Lon:          -97.71,
+					Radius:       5,
 				},
 			}
 			return test{
@@ -457,12 +752,15 @@ func TestGetSubsequentIpAccess(t *testing.T) {
 					return nil
 				},
 				want: &supermandetector.IpAccess{
-					Ip:        "24.242.71.20",
-					Speed:     55,
-					Lat:       30.3773,
-					Lon:       -97.71,
-					Radius:    5,
-					Timestamp: 1514851200,
+					Ip:          "24.242.71.20",
+					Speed:       55,
+					MinSpeed:    55,
+					MaxSpeed:    56,
+					Lat:         30.3773,
+					Lon:         -97.71,
+					Radius:      5,
+					Timestamp:   1514851200,
+					TimestampMs: 1514851200000,
 				},
 			}
 		}(),
@@ -470,13 +768,78 @@ func TestGetSubsequentIpAccess(t *testing.T) {
 			args := args{
 				baseUrl: "http://0.0.0.0:80/",
 				currentRecord: &supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514764800,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
-					Ip_address:     "206.81.252.7",
-					Lat:            39.2293,
-					Lon:            -76.6907,
-					Radius:         10,
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
+				},
+				subsequentRecord: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514851200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e40",
+					Ip_address:   "24.242.71.20",
+					Lat:          30.3773,
+					Lon:          -97.71,
+					Radius:       5,
+				},
+				otherRecords: []*supermandetector.IpAccessRecord{
+					&supermandetector.IpAccessRecord{
+						Username:     "bob",
+						Timestamp_ms: 1514937600000,
+						Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e43",
+						Ip_address:   "91.207.175.104",
+						Lat:          34.0549,
+						Lon:          -118.2578,
+						Radius:       200,
+					},
+					&supermandetector.IpAccessRecord{
+						Username:     "alice",
+						Timestamp_ms: 1514768400000,
+						Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e44",
+						Ip_address:   "91.207.175.104",
+						Lat:          34.0549,
+						Lon:          -118.2578,
+						Radius:       200,
+					},
+				},
+			}
+			return test{
+				name: "Check nearest subsequent ip access of the same user",
+				args: args,
+				checkFunc: func(gotS, wantS *supermandetector.IpAccess) error {
+					if !reflect.DeepEqual(gotS, wantS) {
+
+						return fmt.Errorf("got: %+v, want: %+v", gotS, wantS)
+					}
+					return nil
+				},
+				want: &supermandetector.IpAccess{
+					Ip:          "24.242.71.20",
+					Speed:       55,
+					MinSpeed:    55,
+					MaxSpeed:    56,
+					Lat:         30.3773,
+					Lon:         -97.71,
+					Radius:      5,
+					Timestamp:   1514851200,
+					TimestampMs: 1514851200000,
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				currentRecord: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
 				},
 			}
 			return test{
@@ -502,10 +865,10 @@ func TestGetSubsequentIpAccess(t *testing.T) {
 				defer tt.afterFunc()
 			}
 
-			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl)
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
 
-			if tt.args.ipaccessdb != nil {
-				impl.ipaccessdb = tt.args.ipaccessdb
+			if tt.args.store != nil {
+				impl.store = tt.args.store
 			}
 			if tt.args.geodb != nil {
 				impl.geodb = tt.args.geodb
@@ -515,6 +878,11 @@ func TestGetSubsequentIpAccess(t *testing.T) {
 			if e == nil && tt.args.subsequentRecord != nil {
 				e = impl.RegisterIpAccessRecord(tt.args.subsequentRecord)
 			}
+			for _, r := range tt.args.otherRecords {
+				if e == nil {
+					e = impl.RegisterIpAccessRecord(r)
+				}
+			}
 
 			if tt.wantErr == nil && e != nil {
 				t.Errorf("failed to instantiate, error: %v", e)
@@ -555,13 +923,219 @@ func TestGetSubsequentIpAccess(t *testing.T) {
 	}
 }
 
+func TestCalculateSpeedBounds(t *testing.T) {
+	type args struct {
+		origin            haversine.Coord
+		originRadius      int32
+		destination       haversine.Coord
+		destinationRadius int32
+		milliseconds      int64
+	}
+	type test struct {
+		name    string
+		args    args
+		wantMin int
+		wantMax int
+	}
+	tests := []test{
+		{
+			name: "Check accurate geolocations",
+			args: args{
+				origin:            haversine.Coord{Lat: 34.0549, Lon: -118.2578},
+				originRadius:      200,
+				destination:       haversine.Coord{Lat: 39.2293, Lon: -76.6907},
+				destinationRadius: 10,
+				milliseconds:      3600000,
+			},
+			wantMin: 2180,
+			wantMax: 2441,
+		},
+		{
+			name: "Check elapsed time less than an hour",
+			args: args{
+				origin:            haversine.Coord{Lat: 34.0549, Lon: -118.2578},
+				originRadius:      200,
+				destination:       haversine.Coord{Lat: 39.2293, Lon: -76.6907},
+				destinationRadius: 10,
+				milliseconds:      2400000,
+			},
+			wantMin: 3270,
+			wantMax: 3662,
+		},
+		{
+			name: "Check accuracy radius larger than the distance",
+			args: args{
+				origin:            haversine.Coord{Lat: 30.3773, Lon: -97.71},
+				originRadius:      1000,
+				destination:       haversine.Coord{Lat: 29.4241, Lon: -98.4936},
+				destinationRadius: 5,
+				milliseconds:      3600000,
+			},
+			wantMin: 0,
+			wantMax: 705,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl := new(SupermanDetectorImpl)
+			gotMin, gotMax := impl.CalculateSpeedBounds(tt.args.origin, tt.args.originRadius, tt.args.destination, tt.args.destinationRadius, tt.args.milliseconds)
+			if int(gotMin) != tt.wantMin || int(gotMax) != tt.wantMax {
+				t.Errorf("got: (%v, %v), want: (%v, %v)", gotMin, gotMax, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestGenerateIpAccess(t *testing.T) {
+	type args struct {
+		neighbour *supermandetector.IpAccessRecord
+		current   *supermandetector.IpAccessRecord
+	}
+	type test struct {
+		name string
+		args args
+		want *supermandetector.IpAccess
+	}
+	baltimore := &supermandetector.IpAccessRecord{Timestamp_ms: 1514764800000, Ip_address: "206.81.252.7", Lat: 39.2293, Lon: -76.6907, Radius: 10}
+	tests := []test{
+		{
+			name: "Check speed of accesses an hour apart",
+			args: args{
+				neighbour: &supermandetector.IpAccessRecord{Timestamp_ms: 1514761200000, Ip_address: "91.207.175.104", Lat: 34.0549, Lon: -118.2578, Radius: 200},
+				current:   baltimore,
+			},
+			want: &supermandetector.IpAccess{Ip: "91.207.175.104", Speed: 2311, MinSpeed: 2180, MaxSpeed: 2441, Lat: 34.0549, Lon: -118.2578, Radius: 200, Timestamp: 1514761200, TimestampMs: 1514761200000},
+		},
+		{
+			name: "Check speed of accesses a millisecond apart clamped to int32",
+			args: args{
+				neighbour: &supermandetector.IpAccessRecord{Timestamp_ms: 1514764799999, Ip_address: "91.207.175.104", Lat: 34.0549, Lon: -118.2578, Radius: 200},
+				current:   baltimore,
+			},
+			want: &supermandetector.IpAccess{Ip: "91.207.175.104", Speed: math.MaxInt32, MinSpeed: math.MaxInt32, MaxSpeed: math.MaxInt32, Lat: 34.0549, Lon: -118.2578, Radius: 200, Timestamp: 1514764799, TimestampMs: 1514764799999},
+		},
+		{
+			name: "Check legacy timestamp after 2038 clamped to int32",
+			args: args{
+				neighbour: &supermandetector.IpAccessRecord{Timestamp_ms: 2208988800000, Ip_address: "91.207.175.104", Lat: 34.0549, Lon: -118.2578, Radius: 200},
+				current:   &supermandetector.IpAccessRecord{Timestamp_ms: 2208992400000, Ip_address: "206.81.252.7", Lat: 39.2293, Lon: -76.6907, Radius: 10},
+			},
+			want: &supermandetector.IpAccess{Ip: "91.207.175.104", Speed: 2311, MinSpeed: 2180, MaxSpeed: 2441, Lat: 34.0549, Lon: -118.2578, Radius: 200, Timestamp: math.MaxInt32, TimestampMs: 2208988800000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, _ := NewPolicyStore(&supermandetector.SpeedPolicy{Threshold: 500, Unit: supermandetector.MPH}, "")
+			impl := &SupermanDetectorImpl{policies: policies}
+			got := impl.GenerateIpAccess(tt.args.neighbour, tt.args.neighbour, tt.args.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+				return
+			}
+			if !impl.IsTravelSuspicious(tt.args.current, got) {
+				t.Errorf("got: not suspicious, want: suspicious")
+			}
+		})
+	}
+}
+
+func TestIsTravelSuspicious(t *testing.T) {
+	type args struct {
+		ipRecord *supermandetector.IpAccessRecord
+		ipAccess *supermandetector.IpAccess
+	}
+	type test struct {
+		name string
+		args args
+		want bool
+	}
+	simultaneous := true
+	carrier, other := int64(21928), int64(7922)
+	tests := []test{
+		{
+			name: "Check speed over the threshold",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Timestamp_ms: 1514764800000, Lat: 39.2293, Lon: -76.6907, Radius: 10},
+				ipAccess: &supermandetector.IpAccess{Speed: 2311, MinSpeed: 2180, MaxSpeed: 2441, TimestampMs: 1514761200000, Lat: 34.0549, Lon: -118.2578, Radius: 200},
+			},
+			want: true,
+		},
+		{
+			name: "Check speed under the threshold",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Timestamp_ms: 1514764800000, Lat: 39.2293, Lon: -76.6907, Radius: 10},
+				ipAccess: &supermandetector.IpAccess{Speed: 55, MinSpeed: 55, MaxSpeed: 56, TimestampMs: 1514678400000, Lat: 30.3773, Lon: -97.71, Radius: 5},
+			},
+			want: false,
+		},
+		{
+			name: "Check speed of accesses a millisecond apart beyond the int32 of the response",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Timestamp_ms: 1514764800000, Lat: 39.2293, Lon: -76.6907, Radius: 10},
+				ipAccess: &supermandetector.IpAccess{Speed: math.MaxInt32, MinSpeed: math.MaxInt32, MaxSpeed: math.MaxInt32, TimestampMs: 1514764799999, Lat: 34.0549, Lon: -118.2578, Radius: 200},
+			},
+			want: true,
+		},
+		{
+			name: "Check simultaneous access at the same location",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Lat: 30.3773, Lon: -97.71, Radius: 5},
+				ipAccess: &supermandetector.IpAccess{Lat: 30.2672, Lon: -97.7431, Radius: 20, Simultaneous: &simultaneous},
+			},
+			want: false,
+		},
+		{
+			name: "Check simultaneous access at two places",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Lat: 39.2293, Lon: -76.6907, Radius: 10},
+				ipAccess: &supermandetector.IpAccess{Lat: 34.0549, Lon: -118.2578, Radius: 200, Simultaneous: &simultaneous},
+			},
+			want: true,
+		},
+		{
+			name: "Check travel within the same cellular autonomous system",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Timestamp_ms: 1514764800000, Lat: 39.2293, Lon: -76.6907, Radius: 10, Asn: &carrier, ConnectionType: "Cellular"},
+				ipAccess: &supermandetector.IpAccess{Speed: 2311, MinSpeed: 2180, MaxSpeed: 2441, TimestampMs: 1514761200000, Lat: 34.0549, Lon: -118.2578, Radius: 200, Asn: &carrier, ConnectionType: "Cellular"},
+			},
+			want: false,
+		},
+		{
+			name: "Check travel within the same autonomous system by an untrusted connection type",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Timestamp_ms: 1514764800000, Lat: 39.2293, Lon: -76.6907, Radius: 10, Asn: &carrier, ConnectionType: "Corporate"},
+				ipAccess: &supermandetector.IpAccess{Speed: 2311, MinSpeed: 2180, MaxSpeed: 2441, TimestampMs: 1514761200000, Lat: 34.0549, Lon: -118.2578, Radius: 200, Asn: &carrier, ConnectionType: "Corporate"},
+			},
+			want: true,
+		},
+		{
+			name: "Check travel between two autonomous systems",
+			args: args{
+				ipRecord: &supermandetector.IpAccessRecord{Timestamp_ms: 1514764800000, Lat: 39.2293, Lon: -76.6907, Radius: 10, Asn: &carrier, ConnectionType: "Cellular"},
+				ipAccess: &supermandetector.IpAccess{Speed: 2311, MinSpeed: 2180, MaxSpeed: 2441, TimestampMs: 1514761200000, Lat: 34.0549, Lon: -118.2578, Radius: 200, Asn: &other, ConnectionType: "Cellular"},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, _ := NewPolicyStore(&supermandetector.SpeedPolicy{Threshold: 500, Unit: supermandetector.MPH}, "")
+			impl := &SupermanDetectorImpl{policies: policies, trustedSameASN: []string{"Cellular"}}
+			got := impl.IsTravelSuspicious(tt.args.ipRecord, tt.args.ipAccess)
+			if got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPostIpAccessRequest(t *testing.T) {
 	type args struct {
 		baseUrl          string
 		request          supermandetector.IpAccessRequest
 		precedingRecord  supermandetector.IpAccessRecord
 		subsequentRecord supermandetector.IpAccessRecord
-		ipaccessdbClose  bool
+		storeClose       bool
 		geodbClose       bool
 	}
 	type test struct {
@@ -584,22 +1158,22 @@ func TestPostIpAccessRequest(t *testing.T) {
 					Ip_address:     "206.81.252.7",
 				},
 				precedingRecord: supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514761200,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e42",
-					Ip_address:     "91.207.175.104",
-					Lat:            34.0549,
-					Lon:            -118.2578,
-					Radius:         200,
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
 				},
 				subsequentRecord: supermandetector.IpAccessRecord{
-					Username:       "bob",
-					Unix_timestamp: 1514851200,
-					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e40",
-					Ip_address:     "24.242.71.20",
-					Lat:            30.3773,
-					Lon:            -97.71,
-					Radius:         5,
+					Username:     "bob",
+					Timestamp_ms: 1514851200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e40",
+					Ip_address:   "24.242.71.20",
+					Lat:          30.3773,
+					Lon:          -97.71,
+					Radius:       5,
 				},
 			}
 			return test{
@@ -629,27 +1203,38 @@ func TestPostIpAccessRequest(t *testing.T) {
 				},
 				want: &supermandetector.IpAccessResponse{
 					CurrentGeo: &supermandetector.CurrentGeo{
-						Lat:    39.2293,
-						Lon:    -76.6907,
-						Radius: 10,
+						Lat:         39.2293,
+						Lon:         -76.6907,
+						Radius:      10,
+						Provider:    "maxmind",
+						CountryCode: "US",
+						Subdivision: "Maryland",
+						City:        "Baltimore",
+						TimeZone:    "America/New_York",
 					},
-					TravelToCurrentGeoSuspicious: new(bool),
+					TravelToCurrentGeoSuspicious:   new(bool),
 					TravelFromCurrentGeoSuspicious: new(bool),
 					PrecedingIpAccess: &supermandetector.IpAccess{
-						Ip:        "91.207.175.104",
-						Speed:     2311,
-						Lat:       34.0549,
-						Lon:       -118.2578,
-						Radius:    200,
-						Timestamp: 1514761200,
+						Ip:          "91.207.175.104",
+						Speed:       2311,
+						MinSpeed:    2180,
+						MaxSpeed:    2441,
+						Lat:         34.0549,
+						Lon:         -118.2578,
+						Radius:      200,
+						Timestamp:   1514761200,
+						TimestampMs: 1514761200000,
 					},
 					SubsequentIpAccess: &supermandetector.IpAccess{
-						Ip:        "24.242.71.20",
-						Speed:     55,
-						Lat:       30.3773,
-						Lon:       -97.71,
-						Radius:    5,
-						Timestamp: 1514851200,
+						Ip:          "24.242.71.20",
+						Speed:       55,
+						MinSpeed:    55,
+						MaxSpeed:    56,
+						Lat:         30.3773,
+						Lon:         -97.71,
+						Radius:      5,
+						Timestamp:   1514851200,
+						TimestampMs: 1514851200000,
 					},
 				},
 			}
@@ -665,7 +1250,7 @@ func TestPostIpAccessRequest(t *testing.T) {
 				},
 			}
 			return test{
-				name: "Check error to get city from ip",
+				name: "Check error to validate request",
 				args: args,
 				checkFunc: func(gotS, wantS *supermandetector.IpAccessResponse) error {
 					if !reflect.DeepEqual(gotS, wantS) {
@@ -674,10 +1259,11 @@ func TestPostIpAccessRequest(t *testing.T) {
 					return nil
 				},
 				want: nil,
-				wantErr: &rdl.ResourceError{
-					Code: 200,
-					Message: fmt.Sprintf("Failed to get city from ip, Error:%s", "ipAddress passed to Lookup cannot be nil"),
-				},
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      400,
+					ErrorCode: "INVALID_REQUEST",
+					Message:   fmt.Sprintf("Invalid IpAccessRequest, Error:%s", "IpAccessRequest.ip_address is missing but is a required field"),
+				}},
 			}
 		}(),
 		func() test {
@@ -689,7 +1275,7 @@ func TestPostIpAccessRequest(t *testing.T) {
 					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
 					Ip_address:     "206.81.252.7",
 				},
-				ipaccessdbClose: true,
+				storeClose: true,
 			}
 			return test{
 				name: "Check error to ",
@@ -701,10 +1287,11 @@ func TestPostIpAccessRequest(t *testing.T) {
 					return nil
 				},
 				want: nil,
-				wantErr: &rdl.ResourceError{
-					Code: 200,
-					Message: fmt.Sprintf("Failed to register IpAccessRecord, Error:%s", "sql: database is closed"),
-				},
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      503,
+					ErrorCode: "STORAGE_UNAVAILABLE",
+					Message:   fmt.Sprintf("Failed to get IpAccessRecord, Error:%s", "access store is closed"),
+				}},
 			}
 		}(),
 		func() test {
@@ -728,33 +1315,188 @@ func TestPostIpAccessRequest(t *testing.T) {
 					return nil
 				},
 				want: nil,
-				wantErr: &rdl.ResourceError{
-					Code: 200,
-					Message: fmt.Sprintf("Failed to get city from ip, Error:%s", "cannot call Lookup on a closed database"),
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      503,
+					ErrorCode: "GEO_UNAVAILABLE",
+					Message:   fmt.Sprintf("Failed to get city from ip, Error:%s", "cannot call Lookup on a closed database"),
+				}},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequest{
+					Username:       "bob",
+					Unix_timestamp: 1514764800,
+					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:     "::ffff:10.0.0.1",
 				},
 			}
+			return test{
+				name: "Check error of private IPv4-mapped IPv6 address",
+				args: args,
+				want: nil,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      422,
+					ErrorCode: "GEO_UNRESOLVABLE",
+					Message:   fmt.Sprintf("Failed to get city from ip, Error:%s", "ip address is not a public address: 10.0.0.1"),
+				}},
+			}
 		}(),
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.beforeFunc != nil {
-				tt.beforeFunc(tt)
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequest{
+					Username:       "bob",
+					Unix_timestamp: 1514764800,
+					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:     "10.0.0.1",
+				},
 			}
-			if tt.afterFunc != nil {
-				defer tt.afterFunc()
+			return test{
+				name: "Check error of private ip address",
+				args: args,
+				want: nil,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      422,
+					ErrorCode: "GEO_UNRESOLVABLE",
+					Message:   fmt.Sprintf("Failed to get city from ip, Error:%s", "ip address is not a public address: 10.0.0.1"),
+				}},
 			}
-
-			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl)
-
-			if tt.args.ipaccessdbClose {
-				impl.ipaccessdb.Close()
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequest{
+					Username:       "bob",
+					Unix_timestamp: 1514764800,
+					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:     "198.51.100.1",
+				},
 			}
-			if tt.args.geodbClose {
-				impl.geodb.Close()
+			return test{
+				name: "Check error of ip address not in database",
+				args: args,
+				want: nil,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      404,
+					ErrorCode: "GEO_NOT_FOUND",
+					Message:   fmt.Sprintf("Failed to get city from ip, Error:%s", "ip address is not found in the geolocation database: 198.51.100.1"),
+				}},
 			}
-
-			impl.RegisterIpAccessRecord(&tt.args.subsequentRecord)
-			impl.RegisterIpAccessRecord(&tt.args.precedingRecord)
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequest{
+					Username:       "bob",
+					Unix_timestamp: 1514764800,
+					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:     "206.81.252.7",
+				},
+				precedingRecord: supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+				},
+			}
+			return test{
+				name: "Check error of event uuid reused for a different access",
+				args: args,
+				want: nil,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      409,
+					ErrorCode: "DUPLICATE_EVENT",
+					Message:   fmt.Sprintf("Failed to register IpAccessRecord, Error:%s", "event_uuid 85ad929a-db03-4bf4-9541-8f728fa12e41: event is already registered with a different payload"),
+				}},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequest{
+					Username:       "bob",
+					Unix_timestamp: 1514764800,
+					Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:     "206.81.252.7",
+				},
+				precedingRecord: supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+				},
+				subsequentRecord: supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
+				},
+			}
+			return test{
+				name: "Check retry of registered event",
+				args: args,
+				checkFunc: func(gotS, wantS *supermandetector.IpAccessResponse) error {
+					if !reflect.DeepEqual(gotS, wantS) {
+						return fmt.Errorf("got: %+v, want: %+v", gotS, wantS)
+					}
+					return nil
+				},
+				want: func() *supermandetector.IpAccessResponse {
+					suspicious := true
+					return &supermandetector.IpAccessResponse{
+						CurrentGeo: &supermandetector.CurrentGeo{
+							Lat:    39.2293,
+							Lon:    -76.6907,
+							Radius: 10,
+						},
+						TravelToCurrentGeoSuspicious: &suspicious,
+						PrecedingIpAccess: &supermandetector.IpAccess{
+							Ip:          "91.207.175.104",
+							Speed:       2311,
+							MinSpeed:    2180,
+							MaxSpeed:    2441,
+							Lat:         34.0549,
+							Lon:         -118.2578,
+							Radius:      200,
+							Timestamp:   1514761200,
+							TimestampMs: 1514761200000,
+						},
+					}
+				}(),
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(tt)
+			}
+			if tt.afterFunc != nil {
+				defer tt.afterFunc()
+			}
+
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
+
+			if tt.args.storeClose {
+				impl.store.Close()
+			}
+			if tt.args.geodbClose {
+				impl.geodb.Close()
+			}
+
+			impl.RegisterIpAccessRecord(&tt.args.subsequentRecord)
+			impl.RegisterIpAccessRecord(&tt.args.precedingRecord)
 
 			got, err := impl.PostIpAccessRequest(nil, &tt.args.request)
 			if tt.wantErr == nil && err != nil {
@@ -764,8 +1506,8 @@ func TestPostIpAccessRequest(t *testing.T) {
 				if err == nil {
 					t.Errorf("got nil error, want: %v, got: %v", tt.wantErr, err)
 				}
-				if tt.wantErr.Error() != err.Error() {
-					t.Errorf("error not the same, want: %v, got: %v", tt.wantErr, err)
+				if !reflect.DeepEqual(tt.wantErr, err) {
+					t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
 				}
 			}
 
@@ -779,3 +1521,1235 @@ func TestPostIpAccessRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateIpAccessRequest(t *testing.T) {
+	type args struct {
+		baseUrl         string
+		request         supermandetector.IpAccessRequestV2
+		precedingRecord supermandetector.IpAccessRecord
+	}
+	type test struct {
+		name      string
+		args      args
+		checkFunc func(*SupermanDetectorImpl) error
+		want      *supermandetector.IpAccessResponse
+		wantErr   error
+	}
+	tests := []test{
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+				},
+				precedingRecord: supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+				},
+			}
+			suspicious := true
+			return test{
+				name: "Check success without registering",
+				args: args,
+				checkFunc: func(impl *SupermanDetectorImpl) error {
+					got, err := impl.store.GetIpAccessRecord("85ad929a-db03-4bf4-9541-8f728fa12e41")
+					if err != nil {
+						return err
+					}
+					if got != nil {
+						return fmt.Errorf("evaluated request is registered: %+v", got)
+					}
+					return nil
+				},
+				want: &supermandetector.IpAccessResponse{
+					CurrentGeo: &supermandetector.CurrentGeo{
+						Lat:         39.2293,
+						Lon:         -76.6907,
+						Radius:      10,
+						Provider:    "maxmind",
+						CountryCode: "US",
+						Subdivision: "Maryland",
+						City:        "Baltimore",
+						TimeZone:    "America/New_York",
+					},
+					TravelToCurrentGeoSuspicious: &suspicious,
+					PrecedingIpAccess: &supermandetector.IpAccess{
+						Ip:          "91.207.175.104",
+						Speed:       2311,
+						MinSpeed:    2180,
+						MaxSpeed:    2441,
+						Lat:         34.0549,
+						Lon:         -118.2578,
+						Radius:      200,
+						Timestamp:   1514761200,
+						TimestampMs: 1514761200000,
+					},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+				},
+				precedingRecord: supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514761200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "91.207.175.104",
+					Lat:          34.0549,
+					Lon:          -118.2578,
+					Radius:       200,
+				},
+			}
+			return test{
+				name: "Check the stored copy of the same event not compared as a neighbour",
+				args: args,
+				want: &supermandetector.IpAccessResponse{
+					CurrentGeo: &supermandetector.CurrentGeo{
+						Lat:         39.2293,
+						Lon:         -76.6907,
+						Radius:      10,
+						Provider:    "maxmind",
+						CountryCode: "US",
+						Subdivision: "Maryland",
+						City:        "Baltimore",
+						TimeZone:    "America/New_York",
+					},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+				},
+			}
+			return test{
+				name: "Check error to validate request",
+				args: args,
+				want: nil,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      400,
+					ErrorCode: "INVALID_REQUEST",
+					Message:   fmt.Sprintf("Invalid IpAccessRequest, Error:%s", "IpAccessRequestV2.ip_address is missing but is a required field"),
+				}},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
+			defer impl.Close()
+
+			if tt.args.precedingRecord.Event_uuid != "" {
+				impl.RegisterIpAccessRecord(&tt.args.precedingRecord)
+			}
+
+			got, err := impl.EvaluateIpAccessRequest(nil, &tt.args.request)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+			if tt.checkFunc != nil {
+				if err := tt.checkFunc(impl); err != nil {
+					t.Errorf("check failed, err: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPostIpAccessRequestV2Duplicate(t *testing.T) {
+	type args struct {
+		existing *supermandetector.IpAccessRecord
+		err      error
+	}
+	type test struct {
+		name    string
+		args    args
+		want    *supermandetector.IpAccessResponse
+		wantErr error
+	}
+	dir, err := ioutil.TempDir("", "duplicate")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	static := filepath.Join(dir, "static.json")
+	ioutil.WriteFile(static, []byte(`[{"cidr": "206.81.252.0/24", "lat": 39.2293, "lon": -76.6907, "radius": 10}]`), 0644)
+	config := newTestConfig()
+	config.GeoProviders = []string{GeoProviderStatic}
+	config.GeoStaticFile = static
+	request := supermandetector.IpAccessRequestV2{
+		Username:     "bob",
+		Timestamp_ms: 1514764800000,
+		Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+		Ip_address:   "206.81.252.7",
+	}
+
+	tests := []test{
+		{
+			name: "Check replay of the event registered concurrently",
+			args: args{
+				existing: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+					Lat:          39.2293,
+					Lon:          -76.6907,
+					Radius:       10,
+					Provider:     "static",
+				},
+			},
+			want: &supermandetector.IpAccessResponse{
+				CurrentGeo: &supermandetector.CurrentGeo{
+					Lat:      39.2293,
+					Lon:      -76.6907,
+					Radius:   10,
+					Provider: "static",
+				},
+			},
+		},
+		{
+			name: "Check error of the event registered concurrently and gone again",
+			args: args{},
+			wantErr: &ServiceError{supermandetector.ServiceError{
+				Code:      409,
+				ErrorCode: "DUPLICATE_EVENT",
+				Message:   fmt.Sprintf("Failed to register IpAccessRecord, Error:%v", ErrDuplicateEvent),
+			}},
+		},
+		{
+			name: "Check error to get the event registered concurrently",
+			args: args{err: ErrAccessStoreClosed},
+			wantErr: &ServiceError{supermandetector.ServiceError{
+				Code:      503,
+				ErrorCode: "STORAGE_UNAVAILABLE",
+				Message:   fmt.Sprintf("Failed to register IpAccessRecord, Error:%v", ErrAccessStoreClosed),
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl, err := NewSupermanDetectorImpl("http://0.0.0.0:80/", config)
+			if err != nil {
+				t.Fatalf("failed to instantiate, error: %v", err)
+			}
+			defer impl.Close()
+			impl.store = &racingStore{AccessStore: impl.store, existing: tt.args.existing, err: tt.args.err}
+
+			got, err := impl.PostIpAccessRequestV2(nil, &request)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostIpAccessBatchRequest(t *testing.T) {
+	type args struct {
+		baseUrl string
+		batch   supermandetector.IpAccessBatchRequest
+	}
+	type test struct {
+		name      string
+		args      args
+		checkFunc func(*SupermanDetectorImpl) error
+		want      *supermandetector.IpAccessBatchResponse
+		wantErr   error
+	}
+	tests := []test{
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				batch: supermandetector.IpAccessBatchRequest{
+					Requests: []*supermandetector.IpAccessRequestV2{
+						&supermandetector.IpAccessRequestV2{
+							Username:     "bob",
+							Timestamp_ms: 1514764800000,
+							Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+							Ip_address:   "206.81.252.7",
+						},
+						&supermandetector.IpAccessRequestV2{
+							Username:     "bob",
+							Timestamp_ms: 1514761200000,
+							Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+							Ip_address:   "91.207.175.104",
+						},
+						&supermandetector.IpAccessRequestV2{
+							Username:     "bob",
+							Timestamp_ms: 1514851200000,
+							Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e40",
+						},
+					},
+				},
+			}
+			suspicious := true
+			return test{
+				name: "Check requests out of order and an invalid request in a batch",
+				args: args,
+				checkFunc: func(impl *SupermanDetectorImpl) error {
+					got, err := impl.store.ListIpAccessRecords("bob")
+					if err != nil {
+						return err
+					}
+					if len(got) != 2 {
+						return fmt.Errorf("registered records got: %d, want: 2", len(got))
+					}
+					return nil
+				},
+				want: &supermandetector.IpAccessBatchResponse{
+					Results: []*supermandetector.IpAccessBatchResult{
+						&supermandetector.IpAccessBatchResult{
+							Event_uuid: "85ad929a-db03-4bf4-9541-8f728fa12e41",
+							Response: &supermandetector.IpAccessResponse{
+								CurrentGeo: &supermandetector.CurrentGeo{
+									Lat:         39.2293,
+									Lon:         -76.6907,
+									Radius:      10,
+									Provider:    "maxmind",
+									CountryCode: "US",
+									Subdivision: "Maryland",
+									City:        "Baltimore",
+									TimeZone:    "America/New_York",
+								},
+								TravelToCurrentGeoSuspicious: &suspicious,
+								PrecedingIpAccess: &supermandetector.IpAccess{
+									Ip:          "91.207.175.104",
+									Speed:       2311,
+									MinSpeed:    2180,
+									MaxSpeed:    2441,
+									Lat:         34.0549,
+									Lon:         -118.2578,
+									Radius:      200,
+									Timestamp:   1514761200,
+									TimestampMs: 1514761200000,
+									CountryCode: "US",
+									Subdivision: "California",
+									City:        "Los Angeles",
+									TimeZone:    "America/Los_Angeles",
+								},
+							},
+						},
+						&supermandetector.IpAccessBatchResult{
+							Event_uuid: "85ad929a-db03-4bf4-9541-8f728fa12e42",
+							Response: &supermandetector.IpAccessResponse{
+								CurrentGeo: &supermandetector.CurrentGeo{
+									Lat:         34.0549,
+									Lon:         -118.2578,
+									Radius:      200,
+									Provider:    "maxmind",
+									CountryCode: "US",
+									Subdivision: "California",
+									City:        "Los Angeles",
+									TimeZone:    "America/Los_Angeles",
+								},
+								TravelFromCurrentGeoSuspicious: &suspicious,
+								SubsequentIpAccess: &supermandetector.IpAccess{
+									Ip:          "206.81.252.7",
+									Speed:       2311,
+									MinSpeed:    2180,
+									MaxSpeed:    2441,
+									Lat:         39.2293,
+									Lon:         -76.6907,
+									Radius:      10,
+									Timestamp:   1514764800,
+									TimestampMs: 1514764800000,
+									CountryCode: "US",
+									Subdivision: "Maryland",
+									City:        "Baltimore",
+									TimeZone:    "America/New_York",
+								},
+							},
+						},
+						&supermandetector.IpAccessBatchResult{
+							Event_uuid: "85ad929a-db03-4bf4-9541-8f728fa12e40",
+							Error: &supermandetector.ServiceError{
+								Code:      400,
+								ErrorCode: "INVALID_REQUEST",
+								Message:   fmt.Sprintf("Invalid IpAccessRequest, Error:%s", "IpAccessRequestV2.ip_address is missing but is a required field"),
+							},
+						},
+					},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				batch:   supermandetector.IpAccessBatchRequest{},
+			}
+			return test{
+				name: "Check error of empty batch",
+				args: args,
+				want: nil,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      400,
+					ErrorCode: "INVALID_REQUEST",
+					Message:   "Invalid IpAccessBatchRequest, Error:no request in the batch",
+				}},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
+			defer impl.Close()
+
+			got, err := impl.PostIpAccessBatchRequest(nil, &tt.args.batch)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+			if tt.checkFunc != nil {
+				if err := tt.checkFunc(impl); err != nil {
+					t.Errorf("check failed, err: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUserIpAccessTimeline(t *testing.T) {
+	type args struct {
+		baseUrl string
+		from    *int64
+		to      *int64
+		limit   int32
+		next    string
+	}
+	type test struct {
+		name    string
+		args    args
+		want    *supermandetector.IpAccessTimeline
+		wantErr error
+	}
+	records := testIpAccessRecords()
+	suspicious := false
+	tests := []test{
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				limit:   1,
+			}
+			return test{
+				name: "Check first page of the timeline",
+				args: args,
+				want: &supermandetector.IpAccessTimeline{
+					Username: "bob",
+					Accesses: []*supermandetector.IpAccessTimelineEntry{
+						&supermandetector.IpAccessTimelineEntry{
+							Record:                         records[1],
+							TravelFromCurrentGeoSuspicious: &suspicious,
+							SubsequentIpAccess: &supermandetector.IpAccess{
+								Ip:          "24.242.71.20",
+								Speed:       49,
+								MinSpeed:    43,
+								MaxSpeed:    54,
+								Lat:         30.3773,
+								Lon:         -97.71,
+								Radius:      5,
+								Timestamp:   1514851200,
+								TimestampMs: 1514851200000,
+							},
+						},
+					},
+					Next: EncodeTimelineCursor(1514761200000, "85ad929a-db03-4bf4-9541-8f728fa12e42"),
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				limit:   1,
+				next:    EncodeTimelineCursor(1514761200000, "85ad929a-db03-4bf4-9541-8f728fa12e42"),
+			}
+			return test{
+				name: "Check last page of the timeline",
+				args: args,
+				want: &supermandetector.IpAccessTimeline{
+					Username: "bob",
+					Accesses: []*supermandetector.IpAccessTimelineEntry{
+						&supermandetector.IpAccessTimelineEntry{
+							Record:                       records[0],
+							TravelToCurrentGeoSuspicious: &suspicious,
+							PrecedingIpAccess: &supermandetector.IpAccess{
+								Ip:          "91.207.175.104",
+								Speed:       49,
+								MinSpeed:    43,
+								MaxSpeed:    54,
+								Lat:         34.0549,
+								Lon:         -118.2578,
+								Radius:      200,
+								Timestamp:   1514761200,
+								TimestampMs: 1514761200000,
+							},
+						},
+					},
+				},
+			}
+		}(),
+		func() test {
+			from := int64(1514764800000)
+			to := int64(1514851200000)
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				from:    &from,
+				to:      &to,
+				limit:   100,
+			}
+			return test{
+				name: "Check time window without records",
+				args: args,
+				want: &supermandetector.IpAccessTimeline{
+					Username: "bob",
+					Accesses: []*supermandetector.IpAccessTimelineEntry{},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				limit:   0,
+			}
+			return test{
+				name: "Check error of limit out of range",
+				args: args,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      400,
+					ErrorCode: "INVALID_REQUEST",
+					Message:   "Invalid IpAccessTimeline request, Error:limit 0 is out of range from 1 to 1000",
+				}},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				limit:   100,
+				next:    "!",
+			}
+			return test{
+				name: "Check error of malformed cursor",
+				args: args,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      400,
+					ErrorCode: "INVALID_REQUEST",
+					Message:   `Invalid IpAccessTimeline request, Error:malformed cursor "!"`,
+				}},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
+			defer impl.Close()
+
+			for _, r := range testIpAccessRecords() {
+				impl.RegisterIpAccessRecord(r)
+			}
+
+			got, err := impl.GetUserIpAccessTimeline(nil, "bob", tt.args.from, tt.args.to, tt.args.limit, tt.args.next)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetIpAccessEvent(t *testing.T) {
+	type args struct {
+		baseUrl    string
+		uuid       string
+		lateRecord *supermandetector.IpAccessRecord
+		storeClose bool
+	}
+	type test struct {
+		name    string
+		args    args
+		want    *supermandetector.IpAccessEvent
+		wantErr error
+	}
+	records := testIpAccessRecords()
+	suspicious := false
+	tests := []test{
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				uuid:    "85ad929a-db03-4bf4-9541-8f728fa12e42",
+			}
+			return test{
+				name: "Check success",
+				args: args,
+				want: &supermandetector.IpAccessEvent{
+					Record: records[1],
+					Verdict: &supermandetector.IpAccessResponse{
+						CurrentGeo: &supermandetector.CurrentGeo{
+							Lat:    34.0549,
+							Lon:    -118.2578,
+							Radius: 200,
+						},
+						TravelFromCurrentGeoSuspicious: &suspicious,
+						SubsequentIpAccess: &supermandetector.IpAccess{
+							Ip:          "24.242.71.20",
+							Speed:       49,
+							MinSpeed:    43,
+							MaxSpeed:    54,
+							Lat:         30.3773,
+							Lon:         -97.71,
+							Radius:      5,
+							Timestamp:   1514851200,
+							TimestampMs: 1514851200000,
+						},
+					},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				uuid:    "85ad929a-db03-4bf4-9541-8f728fa12e40",
+				lateRecord: &supermandetector.IpAccessRecord{
+					Username:     "bob",
+					Timestamp_ms: 1514851200000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e44",
+					Ip_address:   "24.242.71.20",
+					Lat:          30.3773,
+					Lon:          -97.71,
+					Radius:       5,
+				},
+			}
+			simultaneous := true
+			return test{
+				name: "Check verdict with an event arrived late",
+				args: args,
+				want: &supermandetector.IpAccessEvent{
+					Record: records[0],
+					Verdict: &supermandetector.IpAccessResponse{
+						CurrentGeo: &supermandetector.CurrentGeo{
+							Lat:    30.3773,
+							Lon:    -97.71,
+							Radius: 5,
+						},
+						TravelToCurrentGeoSuspicious:   &suspicious,
+						TravelFromCurrentGeoSuspicious: &suspicious,
+						PrecedingIpAccess: &supermandetector.IpAccess{
+							Ip:          "91.207.175.104",
+							Speed:       49,
+							MinSpeed:    43,
+							MaxSpeed:    54,
+							Lat:         34.0549,
+							Lon:         -118.2578,
+							Radius:      200,
+							Timestamp:   1514761200,
+							TimestampMs: 1514761200000,
+						},
+						SubsequentIpAccess: &supermandetector.IpAccess{
+							Ip:           "24.242.71.20",
+							Lat:          30.3773,
+							Lon:          -97.71,
+							Radius:       5,
+							Timestamp:    1514851200,
+							TimestampMs:  1514851200000,
+							Simultaneous: &simultaneous,
+						},
+					},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl: "http://0.0.0.0:80/",
+				uuid:    "unknown",
+			}
+			return test{
+				name: "Check error of unknown event uuid",
+				args: args,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      404,
+					ErrorCode: "EVENT_NOT_FOUND",
+					Message:   "No IpAccessRecord for event_uuid: unknown",
+				}},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl:    "http://0.0.0.0:80/",
+				uuid:       "85ad929a-db03-4bf4-9541-8f728fa12e42",
+				storeClose: true,
+			}
+			return test{
+				name: "Check error to get record",
+				args: args,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      503,
+					ErrorCode: "STORAGE_UNAVAILABLE",
+					Message:   fmt.Sprintf("Failed to get IpAccessRecord, Error:%v", ErrAccessStoreClosed),
+				}},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
+			defer impl.Close()
+
+			for _, r := range testIpAccessRecords() {
+				impl.RegisterIpAccessRecord(r)
+			}
+			if tt.args.lateRecord != nil {
+				impl.RegisterIpAccessRecord(tt.args.lateRecord)
+			}
+			if tt.args.storeClose {
+				impl.store.Close()
+			}
+
+			got, err := impl.GetIpAccessEvent(nil, tt.args.uuid)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUserDataExport(t *testing.T) {
+	type args struct {
+		baseUrl  string
+		username string
+		format   string
+	}
+	type test struct {
+		name      string
+		args      args
+		checkFunc func(*supermandetector.UserDataExport, string) error
+		want      *supermandetector.UserDataExport
+		wantErr   error
+	}
+	records := testIpAccessRecords()
+	tests := []test{
+		func() test {
+			args := args{
+				baseUrl:  "http://0.0.0.0:80/",
+				username: "bob",
+				format:   "csv",
+			}
+			return test{
+				name: "Check export in CSV",
+				args: args,
+				checkFunc: func(got *supermandetector.UserDataExport, audit string) error {
+					var b bytes.Buffer
+					err := WriteUserDataExportCSV(&b, got)
+					if err != nil {
+						return err
+					}
+					want := "username,timestamp_ms,event_uuid,ip_address,lat,lon,radius,principal,provider,label,is_tor,is_anonymizer,is_hosting,asn,as_organization,connection_type,country_code,subdivision,city,time_zone\n" +
+						"bob,1514761200000,85ad929a-db03-4bf4-9541-8f728fa12e42,91.207.175.104,34.0549,-118.2578,200,,,,,,,,,,,,,\n" +
+						"bob,1514851200000,85ad929a-db03-4bf4-9541-8f728fa12e40,24.242.71.20,30.3773,-97.71,5,,,,,,,,,,,,,\n"
+					if b.String() != want {
+						return fmt.Errorf("csv got: %q, want: %q", b.String(), want)
+					}
+					wantAudit := `{"time":"2018-01-01T00:00:00Z","action":"export","principal":"","username":"bob","records":2}` + "\n"
+					if audit != wantAudit {
+						return fmt.Errorf("audit got: %q, want: %q", audit, wantAudit)
+					}
+					return nil
+				},
+				want: &supermandetector.UserDataExport{
+					Username: "bob",
+					Records:  []*supermandetector.IpAccessRecord{records[1], records[0]},
+					Policy:   &supermandetector.SpeedPolicy{Threshold: 100, Unit: supermandetector.MPH},
+					Groups:   []string{"travellers"},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl:  "http://0.0.0.0:80/",
+				username: "bob",
+				format:   "xml",
+			}
+			return test{
+				name: "Check error of unknown format",
+				args: args,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      400,
+					ErrorCode: "INVALID_REQUEST",
+					Message:   `Invalid UserDataExport request, Error:unknown format "xml"`,
+				}},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
+			defer impl.Close()
+
+			var audit bytes.Buffer
+			impl.audit = &AuditLog{w: &audit, now: func() time.Time { return time.Unix(1514764800, 0) }}
+			for _, r := range testIpAccessRecords() {
+				impl.RegisterIpAccessRecord(r)
+			}
+			impl.policies.PutUserPolicy("bob", &supermandetector.SpeedPolicy{Threshold: 100, Unit: supermandetector.MPH})
+			impl.policies.PutGroup(&supermandetector.SpeedPolicyGroup{
+				Name:      "travellers",
+				Policy:    &supermandetector.SpeedPolicy{Threshold: 1000, Unit: supermandetector.MPH},
+				Usernames: []string{"alice", "bob"},
+			})
+
+			got, err := impl.GetUserDataExport(nil, tt.args.username, tt.args.format)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+			if tt.checkFunc != nil {
+				if err := tt.checkFunc(got, audit.String()); err != nil {
+					t.Errorf("check failed, err: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteUserData(t *testing.T) {
+	type args struct {
+		baseUrl   string
+		username  string
+		auditFail bool
+	}
+	type test struct {
+		name      string
+		args      args
+		checkFunc func(*SupermanDetectorImpl, string) error
+		want      *supermandetector.UserDataErasure
+		wantErr   error
+	}
+	tests := []test{
+		func() test {
+			args := args{
+				baseUrl:  "http://0.0.0.0:80/",
+				username: "bob",
+			}
+			return test{
+				name: "Check erasure of the records and the speed policies",
+				args: args,
+				checkFunc: func(impl *SupermanDetectorImpl, audit string) error {
+					list, err := impl.store.ListIpAccessRecords("bob")
+					if err != nil {
+						return err
+					}
+					if len(list) != 0 {
+						return fmt.Errorf("records not erased: %+v", list)
+					}
+					list, err = impl.store.ListIpAccessRecords("alice")
+					if err != nil {
+						return err
+					}
+					if len(list) != 1 {
+						return fmt.Errorf("records of another user erased: %+v", list)
+					}
+					policy, groups := impl.policies.UserPolicy("bob")
+					if policy != nil || len(groups) != 0 {
+						return fmt.Errorf("speed policies not erased: %+v, %+v", policy, groups)
+					}
+					_, groups = impl.policies.UserPolicy("alice")
+					if !reflect.DeepEqual(groups, []string{"travellers"}) {
+						return fmt.Errorf("groups of another user got: %+v", groups)
+					}
+					wantAudit := `{"time":"2018-01-01T00:00:00Z","action":"erase_request","principal":"","username":"bob","records":2}` + "\n" +
+						`{"time":"2018-01-01T00:00:00Z","action":"erase","principal":"","username":"bob","records":2}` + "\n"
+					if audit != wantAudit {
+						return fmt.Errorf("audit got: %q, want: %q", audit, wantAudit)
+					}
+					return nil
+				},
+				want: &supermandetector.UserDataErasure{
+					Username: "bob",
+					Records:  2,
+					Policy:   true,
+					Groups:   []string{"travellers"},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl:  "http://0.0.0.0:80/",
+				username: "carol",
+			}
+			return test{
+				name: "Check erasure of unknown user",
+				args: args,
+				want: &supermandetector.UserDataErasure{
+					Username: "carol",
+					Records:  0,
+					Policy:   false,
+					Groups:   []string{},
+				},
+			}
+		}(),
+		func() test {
+			args := args{
+				baseUrl:   "http://0.0.0.0:80/",
+				username:  "bob",
+				auditFail: true,
+			}
+			return test{
+				name: "Check nothing erased when the audit log fails",
+				args: args,
+				checkFunc: func(impl *SupermanDetectorImpl, audit string) error {
+					list, err := impl.store.ListIpAccessRecords("bob")
+					if err != nil {
+						return err
+					}
+					if len(list) != 2 {
+						return fmt.Errorf("records erased without audit: %+v", list)
+					}
+					policy, _ := impl.policies.UserPolicy("bob")
+					if policy == nil {
+						return fmt.Errorf("speed policy erased without audit")
+					}
+					return nil
+				},
+				want: nil,
+				wantErr: &ServiceError{supermandetector.ServiceError{
+					Code:      503,
+					ErrorCode: "STORAGE_UNAVAILABLE",
+					Message:   "Failed to write audit log, Error:disk full",
+				}},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl, _ := NewSupermanDetectorImpl(tt.args.baseUrl, newTestConfig())
+			defer impl.Close()
+
+			var audit bytes.Buffer
+			impl.audit = &AuditLog{w: &audit, now: func() time.Time { return time.Unix(1514764800, 0) }}
+			if tt.args.auditFail {
+				impl.audit.w = failingWriter{fmt.Errorf("disk full")}
+			}
+			for _, r := range testIpAccessRecords() {
+				impl.RegisterIpAccessRecord(r)
+			}
+			impl.policies.PutUserPolicy("bob", &supermandetector.SpeedPolicy{Threshold: 100, Unit: supermandetector.MPH})
+			impl.policies.PutGroup(&supermandetector.SpeedPolicyGroup{
+				Name:      "travellers",
+				Policy:    &supermandetector.SpeedPolicy{Threshold: 1000, Unit: supermandetector.MPH},
+				Usernames: []string{"alice", "bob"},
+			})
+
+			got, err := impl.DeleteUserData(nil, tt.args.username)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %+v, got: %+v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+			if tt.checkFunc != nil {
+				if err := tt.checkFunc(impl, audit.String()); err != nil {
+					t.Errorf("check failed, err: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestGeoOverrides(t *testing.T) {
+	type args struct {
+		baseUrl   string
+		overrides string
+		request   supermandetector.IpAccessRequestV2
+	}
+	type test struct {
+		name       string
+		args       args
+		want       *supermandetector.CurrentGeo
+		wantStatus *supermandetector.ServiceStatus
+	}
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []test{
+		func() test {
+			path := filepath.Join(dir, "overrides.json")
+			ioutil.WriteFile(path, []byte(`[{"cidr": "206.81.252.0/24", "lat": 37.7749, "lon": -122.4194, "radius": 1, "label": "sf-vpn"}]`), 0644)
+			args := args{
+				baseUrl:   "http://0.0.0.0:80/",
+				overrides: path,
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "206.81.252.7",
+				},
+			}
+			return test{
+				name: "Check corporate override",
+				args: args,
+				want: &supermandetector.CurrentGeo{
+					Lat:      37.7749,
+					Lon:      -122.4194,
+					Radius:   1,
+					Provider: "override",
+					Label:    "sf-vpn",
+				},
+				wantStatus: &supermandetector.ServiceStatus{
+					GeoProviders: []string{"override", "static"},
+					Overrides: &supermandetector.GeoOverrideStatus{
+						Path:    path,
+						Entries: 1,
+					},
+				},
+			}
+		}(),
+		func() test {
+			path := filepath.Join(dir, "private.json")
+			ioutil.WriteFile(path, []byte(`[{"cidr": "10.0.0.0/8", "lat": 35.6895, "lon": 139.6917, "radius": 1, "label": "tokyo-office"}]`), 0644)
+			args := args{
+				baseUrl:   "http://0.0.0.0:80/",
+				overrides: path,
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "10.1.1.1",
+				},
+			}
+			return test{
+				name: "Check corporate override of a private address",
+				args: args,
+				want: &supermandetector.CurrentGeo{
+					Lat:      35.6895,
+					Lon:      139.6917,
+					Radius:   1,
+					Provider: "override",
+					Label:    "tokyo-office",
+				},
+				wantStatus: &supermandetector.ServiceStatus{
+					GeoProviders: []string{"override", "static"},
+					Overrides: &supermandetector.GeoOverrideStatus{
+						Path:    path,
+						Entries: 1,
+					},
+				},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static := filepath.Join(dir, "static.json")
+			ioutil.WriteFile(static, []byte(`[]`), 0644)
+			config := newTestConfig()
+			config.GeoProviders = []string{GeoProviderStatic}
+			config.GeoStaticFile = static
+			config.GeoOverrideFile = tt.args.overrides
+			impl, err := NewSupermanDetectorImpl(tt.args.baseUrl, config)
+			if err != nil {
+				t.Errorf("failed to instantiate, error: %v", err)
+				return
+			}
+			defer impl.Close()
+
+			response, err := impl.PostIpAccessRequestV2(nil, &tt.args.request)
+			if err != nil {
+				t.Errorf("failed to post, error: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(response.CurrentGeo, tt.want) {
+				t.Errorf("got: %+v, want: %+v", response.CurrentGeo, tt.want)
+			}
+
+			status, _ := impl.GetServiceStatus(nil)
+			tt.wantStatus.Overrides.LoadedAt = status.Overrides.LoadedAt
+			if !reflect.DeepEqual(status, tt.wantStatus) {
+				t.Errorf("status got: %+v, want: %+v", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIsNetworkSuspicious(t *testing.T) {
+	type args struct {
+		baseUrl string
+		request supermandetector.IpAccessRequestV2
+	}
+	type test struct {
+		name string
+		args args
+		want *supermandetector.IpAccessResponse
+	}
+	dir, err := ioutil.TempDir("", "network")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	static := filepath.Join(dir, "static.json")
+	ioutil.WriteFile(static, []byte(`[{"cidr": "0.0.0.0/0", "lat": 39.2293, "lon": -76.6907, "radius": 10}]`), 0644)
+	tor := filepath.Join(dir, "tor.txt")
+	ioutil.WriteFile(tor, []byte("162.247.74.201\n"), 0644)
+	hosting := filepath.Join(dir, "hosting.txt")
+	ioutil.WriteFile(hosting, []byte("3.0.0.0/9\n"), 0644)
+
+	yes, no := true, false
+	tests := []test{
+		{
+			name: "Check access from Tor exit node",
+			args: args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e41",
+					Ip_address:   "162.247.74.201",
+				},
+			},
+			want: &supermandetector.IpAccessResponse{
+				IsTor:             &yes,
+				IsAnonymizer:      &yes,
+				IsHosting:         &no,
+				NetworkSuspicious: &yes,
+			},
+		},
+		{
+			name: "Check access from hosting provider not configured as suspicious",
+			args: args{
+				baseUrl: "http://0.0.0.0:80/",
+				request: supermandetector.IpAccessRequestV2{
+					Username:     "bob",
+					Timestamp_ms: 1514764800000,
+					Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+					Ip_address:   "3.1.2.3",
+				},
+			},
+			want: &supermandetector.IpAccessResponse{
+				IsTor:             &no,
+				IsAnonymizer:      &no,
+				IsHosting:         &yes,
+				NetworkSuspicious: &no,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newTestConfig()
+			config.GeoProviders = []string{GeoProviderStatic}
+			config.GeoStaticFile = static
+			config.TorExitFile = tor
+			config.HostingFile = hosting
+			config.SuspiciousNetworks = []string{NetworkTor, NetworkAnonymizer}
+			impl, err := NewSupermanDetectorImpl(tt.args.baseUrl, config)
+			if err != nil {
+				t.Errorf("failed to instantiate, error: %v", err)
+				return
+			}
+			defer impl.Close()
+
+			got, err := impl.PostIpAccessRequestV2(nil, &tt.args.request)
+			if err != nil {
+				t.Errorf("failed to post, error: %v", err)
+				return
+			}
+			got = &supermandetector.IpAccessResponse{
+				IsTor:             got.IsTor,
+				IsAnonymizer:      got.IsAnonymizer,
+				IsHosting:         got.IsHosting,
+				NetworkSuspicious: got.NetworkSuspicious,
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+
+			// the flags are stored with the record to be replayed alike
+			replayed, err := impl.PostIpAccessRequestV2(nil, &tt.args.request)
+			if err != nil {
+				t.Errorf("failed to replay, error: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(replayed.IsTor, tt.want.IsTor) || !reflect.DeepEqual(replayed.NetworkSuspicious, tt.want.NetworkSuspicious) {
+				t.Errorf("replayed got: %+v, want: %+v", replayed, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceName(t *testing.T) {
+	type args struct {
+		city        string
+		countryCode string
+		lat         float64
+		lon         float64
+	}
+	type test struct {
+		name string
+		args args
+		want string
+	}
+	tests := []test{
+		{
+			name: "Check city and country",
+			args: args{city: "Los Angeles", countryCode: "US", lat: 34.0549, lon: -118.2578},
+			want: "Los Angeles, US",
+		},
+		{
+			name: "Check country only",
+			args: args{countryCode: "US", lat: 37.751, lon: -97.822},
+			want: "US",
+		},
+		{
+			name: "Check coordinates without names",
+			args: args{lat: 39.2293, lon: -76.6907},
+			want: "39.2293,-76.6907",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PlaceName(tt.args.city, tt.args.countryCode, tt.args.lat, tt.args.lon)
+			if got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log"
+	"net"
+)
+
+// ASNInfo is the autonomous system and the connection type of an ip address, the fields are empty when they are unknown
+type ASNInfo struct {
+	ASN            *int64
+	Organization   string
+	ConnectionType string
+}
+
+// ASNLookup is an implementation to look up the autonomous system of an ip address by GeoLite2 ASN database,
+// and its connection type by MaxMind Connection Type database
+type ASNLookup struct {
+	asn            *GeoDB
+	connectionType *GeoDB
+}
+
+// NewASNLookup is an implementation to open the databases of the configuration which are present, nil if none is present
+func NewASNLookup(config *Config) (*ASNLookup, error) {
+	var err error
+	l := &ASNLookup{}
+	l.asn, err = openGeoDBIfPresent(config.ASNDBPath)
+	if err != nil {
+		return nil, err
+	}
+	l.connectionType, err = openGeoDBIfPresent(config.ConnectionTypeDBPath)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	if l.asn == nil && l.connectionType == nil {
+		return nil, nil
+	}
+
+	return l, nil
+}
+
+// Lookup is an implementation to look up the autonomous system and the connection type of the ip address.
+// A failure of a database is logged, and the fields of it are left empty.
+func (l *ASNLookup) Lookup(ip net.IP) ASNInfo {
+	info := ASNInfo{}
+	if l.asn != nil {
+		asn, err := l.asn.ASN(ip)
+		if err != nil {
+			log.Printf("Failed to get ASN of %s, Error:%v\n", ip, err)
+		} else if asn.AutonomousSystemNumber != 0 {
+			info.ASN = new(int64)
+			*info.ASN = int64(asn.AutonomousSystemNumber)
+			info.Organization = asn.AutonomousSystemOrganization
+		}
+	}
+	if l.connectionType != nil {
+		connectionType, err := l.connectionType.ConnectionType(ip)
+		if err != nil {
+			log.Printf("Failed to get connection type of %s, Error:%v\n", ip, err)
+		} else {
+			info.ConnectionType = connectionType.ConnectionType
+		}
+	}
+
+	return info
+}
+
+// Close is an implementation to close the databases which are open
+func (l *ASNLookup) Close() error {
+	var err error
+	for _, db := range []*GeoDB{l.asn, l.connectionType} {
+		if db == nil {
+			continue
+		}
+		if e := db.Close(); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
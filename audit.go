@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actions of AuditEntry on the personal data of a user.
+// An erasure is recorded before it starts by erase_request, whose number of records is not known yet, and once done by erase.
+const (
+	AuditActionExport       = "export"
+	AuditActionEraseRequest = "erase_request"
+	AuditActionErase        = "erase"
+)
+
+// AuditEntry is a record of an operation on the personal data of a user
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Principal string    `json:"principal"`
+	Username  string    `json:"username"`
+	Records   int       `json:"records"`
+}
+
+// AuditLog is an implementation to append AuditEntry as JSON lines, to stderr unless the path is given
+type AuditLog struct {
+	mu  sync.Mutex
+	w   io.Writer
+	f   *os.File
+	now func() time.Time
+}
+
+// NewAuditLog is an implementation to open the audit log at the path for append
+func NewAuditLog(path string) (*AuditLog, error) {
+	if path == "" {
+		return &AuditLog{w: os.Stderr, now: time.Now}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditLog{w: f, f: f, now: time.Now}, nil
+}
+
+// Record is an implementation to append an entry of the action on the user by the principal
+func (al *AuditLog) Record(action string, principal string, username string, records int) error {
+	b, err := json.Marshal(&AuditEntry{
+		Time:      al.now().UTC(),
+		Action:    action,
+		Principal: principal,
+		Username:  username,
+		Records:   records,
+	})
+	if err != nil {
+		return err
+	}
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	_, err = al.w.Write(append(b, '\n'))
+	if err != nil {
+		return err
+	}
+	if al.f != nil {
+		return al.f.Sync()
+	}
+
+	return nil
+}
+
+// Close is an implementation to close the audit log file
+func (al *AuditLog) Close() error {
+	if al.f == nil {
+		return nil
+	}
+
+	return al.f.Close()
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// apiKeyHeader is the header the api key is sent in
+const apiKeyHeader = "X-Api-Key"
+
+// bearerTokenHeader is the header the bearer token is sent in
+const bearerTokenHeader = "Authorization"
+
+// AuthConfig is the credentials file of the api keys, the secrets to verify the bearer tokens
+// and the principals, in the form of domain.name, authorized to administer the speed policies and the user data
+type AuthConfig struct {
+	APIKeys      []APIKey `json:"api_keys"`
+	TokenSecrets []string `json:"token_secrets"`
+	Admins       []string `json:"admins"`
+}
+
+// APIKey is a static api key and the principal it authenticates
+type APIKey struct {
+	Domain string `json:"domain"`
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+}
+
+// LoadAuthConfig is an implementation to read the credentials file, an empty one if the path is empty
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+	config := &AuthConfig{}
+	if path == "" {
+		return config, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// Authenticators is an implementation to initialize the authenticators of the credentials, none if no credentials are given
+func (config *AuthConfig) Authenticators() ([]rdl.Authenticator, error) {
+	if len(config.APIKeys) == 0 && len(config.TokenSecrets) == 0 {
+		return nil, nil
+	}
+
+	authns := []rdl.Authenticator{}
+	if len(config.APIKeys) > 0 {
+		authn, err := NewAPIKeyAuthenticator(config.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authns = append(authns, authn)
+	}
+	if len(config.TokenSecrets) > 0 {
+		authn, err := NewBearerTokenAuthenticator(config.TokenSecrets)
+		if err != nil {
+			return nil, err
+		}
+		authns = append(authns, authn)
+	}
+
+	return authns, nil
+}
+
+// APIKeyAuthenticator is an implementation of rdl.Authenticator to authenticate the static api keys
+type APIKeyAuthenticator struct {
+	// keys is keyed by the sha256 digest of the api key not to compare the key itself in variable time
+	keys map[[sha256.Size]byte]APIKey
+}
+
+// NewAPIKeyAuthenticator is an implementation to initialize an APIKeyAuthenticator
+func NewAPIKeyAuthenticator(apiKeys []APIKey) (*APIKeyAuthenticator, error) {
+	authn := &APIKeyAuthenticator{
+		keys: map[[sha256.Size]byte]APIKey{},
+	}
+	for _, apiKey := range apiKeys {
+		if apiKey.Name == "" || apiKey.Key == "" {
+			return nil, fmt.Errorf("api key requires a name and a key")
+		}
+		digest := sha256.Sum256([]byte(apiKey.Key))
+		if _, ok := authn.keys[digest]; ok {
+			return nil, fmt.Errorf("api key of %s is already registered", apiKey.Name)
+		}
+		authn.keys[digest] = apiKey
+	}
+
+	return authn, nil
+}
+
+// HTTPHeader is an implementation to get the header the api key is sent in
+func (authn *APIKeyAuthenticator) HTTPHeader() string {
+	return apiKeyHeader
+}
+
+// Authenticate is an implementation to get the principal of the api key, or nil if the key is unknown
+func (authn *APIKeyAuthenticator) Authenticate(creds string) rdl.Principal {
+	apiKey, ok := authn.keys[sha256.Sum256([]byte(creds))]
+	if !ok {
+		return nil
+	}
+
+	return &SimplePrincipal{
+		Domain:      apiKey.Domain,
+		Name:        apiKey.Name,
+		Credentials: creds,
+		HTTPHeader:  apiKeyHeader,
+	}
+}
+
+// BearerTokenAuthenticator is an implementation of rdl.Authenticator to authenticate the bearer tokens in the form of JWT signed with HS256
+type BearerTokenAuthenticator struct {
+	// secrets are tried in order to allow rotating the secret
+	secrets [][]byte
+	now     func() time.Time
+}
+
+// bearerTokenClaims is the claims of the bearer token
+type bearerTokenClaims struct {
+	Domain    string `json:"domain"`
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf"`
+}
+
+// NewBearerTokenAuthenticator is an implementation to initialize a BearerTokenAuthenticator
+func NewBearerTokenAuthenticator(secrets []string) (*BearerTokenAuthenticator, error) {
+	authn := &BearerTokenAuthenticator{
+		now: time.Now,
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			return nil, fmt.Errorf("token secret must not be empty")
+		}
+		authn.secrets = append(authn.secrets, []byte(secret))
+	}
+
+	return authn, nil
+}
+
+// HTTPHeader is an implementation to get the header the bearer token is sent in
+func (authn *BearerTokenAuthenticator) HTTPHeader() string {
+	return bearerTokenHeader
+}
+
+// Authenticate is an implementation to get the principal of the subject of the bearer token, or nil if the token is invalid or expired
+func (authn *BearerTokenAuthenticator) Authenticate(creds string) rdl.Principal {
+	if len(creds) < 7 || !strings.EqualFold(creds[:7], "Bearer ") {
+		return nil
+	}
+	token := strings.TrimSpace(creds[7:])
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if decodeTokenPart(parts[0], &header) != nil || header.Alg != "HS256" {
+		return nil
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !authn.verify(parts[0]+"."+parts[1], signature) {
+		return nil
+	}
+	var claims bearerTokenClaims
+	if decodeTokenPart(parts[1], &claims) != nil || claims.Subject == "" {
+		return nil
+	}
+	now := authn.now().Unix()
+	if claims.ExpiresAt != 0 && now >= claims.ExpiresAt {
+		return nil
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return nil
+	}
+
+	return &SimplePrincipal{
+		Domain:      claims.Domain,
+		Name:        claims.Subject,
+		Credentials: creds,
+		HTTPHeader:  bearerTokenHeader,
+	}
+}
+
+func (authn *BearerTokenAuthenticator) verify(signed string, signature []byte) bool {
+	for _, secret := range authn.secrets {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		if hmac.Equal(mac.Sum(nil), signature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func decodeTokenPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
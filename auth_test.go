@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+func signTestToken(header, claims, secret string) string {
+	signed := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	authn, err := NewAPIKeyAuthenticator([]APIKey{
+		{Domain: "security", Name: "collector", Key: "s3cr3t"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		creds string
+		want  rdl.Principal
+	}{
+		{
+			name:  "Test case 1 (known key)",
+			creds: "s3cr3t",
+			want:  &SimplePrincipal{Domain: "security", Name: "collector", Credentials: "s3cr3t", HTTPHeader: "X-Api-Key"},
+		},
+		{
+			name:  "Test case 2 (unknown key)",
+			creds: "s3cr3t2",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := authn.Authenticate(tt.creds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBearerTokenAuthenticator(t *testing.T) {
+	authn, err := NewBearerTokenAuthenticator([]string{"current", "previous"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn.now = func() time.Time {
+		return time.Unix(1514764800, 0)
+	}
+
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	type test struct {
+		name  string
+		creds string
+		want  rdl.Principal
+	}
+	tests := []test{
+		func() test {
+			creds := "Bearer " + signTestToken(hs256, `{"sub":"collector","domain":"security","exp":1514768400}`, "current")
+			return test{
+				name:  "Test case 1 (valid token)",
+				creds: creds,
+				want:  &SimplePrincipal{Domain: "security", Name: "collector", Credentials: creds, HTTPHeader: "Authorization"},
+			}
+		}(),
+		func() test {
+			creds := "bearer " + signTestToken(hs256, `{"sub":"collector"}`, "previous")
+			return test{
+				name:  "Test case 2 (token signed with the previous secret)",
+				creds: creds,
+				want:  &SimplePrincipal{Name: "collector", Credentials: creds, HTTPHeader: "Authorization"},
+			}
+		}(),
+		{
+			name:  "Test case 3 (expired token)",
+			creds: "Bearer " + signTestToken(hs256, `{"sub":"collector","exp":1514764800}`, "current"),
+			want:  nil,
+		},
+		{
+			name:  "Test case 4 (token not valid yet)",
+			creds: "Bearer " + signTestToken(hs256, `{"sub":"collector","nbf":1514768400}`, "current"),
+			want:  nil,
+		},
+		{
+			name:  "Test case 5 (unknown secret)",
+			creds: "Bearer " + signTestToken(hs256, `{"sub":"collector"}`, "unknown"),
+			want:  nil,
+		},
+		{
+			name:  "Test case 6 (unsigned token)",
+			creds: "Bearer " + signTestToken(`{"alg":"none"}`, `{"sub":"collector"}`, "current"),
+			want:  nil,
+		},
+		{
+			name:  "Test case 7 (token without subject)",
+			creds: "Bearer " + signTestToken(hs256, `{"exp":1514768400}`, "current"),
+			want:  nil,
+		},
+		{
+			name:  "Test case 8 (not a bearer token)",
+			creds: "Basic Ym9iOnBhc3N3b3Jk",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := authn.Authenticate(tt.creds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	f, err := ioutil.TempFile("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"api_keys":[{"domain":"security","name":"collector","key":"s3cr3t"}],"token_secrets":["current"]}`)
+	f.Close()
+
+	authConfig, err := LoadAuthConfig(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	authns, err := authConfig.Authenticators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies, err := NewPolicyStore(&supermandetector.SpeedPolicy{Threshold: 500, Unit: supermandetector.MPH}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		authRequired bool
+		header       http.Header
+		want         int
+	}{
+		{
+			name:         "Test case 1 (api key)",
+			authRequired: true,
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusOK,
+		},
+		{
+			name:         "Test case 2 (bearer token)",
+			authRequired: true,
+			header:       http.Header{"Authorization": {"Bearer " + signTestToken(`{"alg":"HS256"}`, `{"sub":"collector"}`, "current")}},
+			want:         http.StatusOK,
+		},
+		{
+			name:         "Test case 3 (wrong api key)",
+			authRequired: true,
+			header:       http.Header{"X-Api-Key": {"wrong"}},
+			want:         http.StatusUnauthorized,
+		},
+		{
+			name:         "Test case 4 (no credentials)",
+			authRequired: true,
+			header:       http.Header{},
+			want:         http.StatusUnauthorized,
+		},
+		{
+			name:         "Test case 5 (no credentials configured)",
+			authRequired: false,
+			header:       http.Header{},
+			want:         http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl := &SupermanDetectorImpl{
+				policies:     policies,
+				authns:       authns,
+				authRequired: tt.authRequired,
+			}
+			handler := supermandetector.Init(impl, "http://0.0.0.0:80/", impl, impl.Authenticators()...)
+
+			request := httptest.NewRequest(http.MethodGet, "/policies", nil)
+			request.Header = tt.header
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %v, want %v", recorder.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	authConfig := &AuthConfig{
+		APIKeys: []APIKey{
+			{Domain: "security", Name: "collector", Key: "s3cr3t"},
+			{Domain: "security", Name: "admin", Key: "4dm1n"},
+		},
+		Admins: []string{"security.admin"},
+	}
+	authns, err := authConfig.Authenticators()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		authRequired bool
+		method       string
+		path         string
+		header       http.Header
+		want         int
+	}{
+		{
+			name:         "Test case 1 (admin updating a policy)",
+			authRequired: true,
+			method:       http.MethodPut,
+			path:         "/policies/users/bob",
+			header:       http.Header{"X-Api-Key": {"4dm1n"}},
+			want:         http.StatusOK,
+		},
+		{
+			name:         "Test case 2 (ingest key updating a policy)",
+			authRequired: true,
+			method:       http.MethodPut,
+			path:         "/policies/users/bob",
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusForbidden,
+		},
+		{
+			name:         "Test case 3 (ingest key deleting a group)",
+			authRequired: true,
+			method:       http.MethodDelete,
+			path:         "/policies/groups/airline-crew",
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusForbidden,
+		},
+		{
+			name:         "Test case 4 (ingest key erasing a user)",
+			authRequired: true,
+			method:       http.MethodDelete,
+			path:         "/users/bob",
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusForbidden,
+		},
+		{
+			name:         "Test case 5 (ingest key exporting a user)",
+			authRequired: true,
+			method:       http.MethodGet,
+			path:         "/users/bob/export",
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusForbidden,
+		},
+		{
+			name:         "Test case 6 (ingest key reading the policies)",
+			authRequired: true,
+			method:       http.MethodGet,
+			path:         "/policies",
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusOK,
+		},
+		{
+			name:         "Test case 7 (no credentials)",
+			authRequired: true,
+			method:       http.MethodPut,
+			path:         "/policies/users/bob",
+			header:       http.Header{},
+			want:         http.StatusForbidden,
+		},
+		{
+			name:         "Test case 8 (no credentials configured)",
+			authRequired: false,
+			method:       http.MethodPut,
+			path:         "/policies/users/bob",
+			header:       http.Header{},
+			want:         http.StatusOK,
+		},
+		{
+			name:         "Test case 9 (ingest key reading the accesses of a user)",
+			authRequired: true,
+			method:       http.MethodGet,
+			path:         "/users/bob/accesses",
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusForbidden,
+		},
+		{
+			name:         "Test case 10 (ingest key reading an event)",
+			authRequired: true,
+			method:       http.MethodGet,
+			path:         "/events/85ad929a-db03-4bf4-9541-8f728fa12e41",
+			header:       http.Header{"X-Api-Key": {"s3cr3t"}},
+			want:         http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := NewPolicyStore(&supermandetector.SpeedPolicy{Threshold: 500, Unit: supermandetector.MPH}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			impl := &SupermanDetectorImpl{
+				policies:     policies,
+				authns:       authns,
+				authRequired: tt.authRequired,
+				admins:       authConfig.Admins,
+			}
+			handler := supermandetector.Init(impl, "http://0.0.0.0:80/", impl, impl.Authenticators()...)
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"threshold":1000,"unit":"KMH"}`))
+			request.Header = tt.header
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %v, want %v", recorder.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config is a set of configurations to initialize a SupermanDetectorImpl
+type Config struct {
+	GeoOverrideFile      string            `json:"geo_override_file"`
+	GeoProviders         []string          `json:"geo_providers"`
+	GeoStaticFile        string            `json:"geo_static_file"`
+	GeoCSVFile           string            `json:"geo_csv_file"`
+	GeoCSVRadius         int               `json:"geo_csv_radius"`
+	GeoDBPath            string            `json:"geodb_path"`
+	GeoLocale            string            `json:"geo_locale"`
+	TorExitFile          string            `json:"tor_exit_file"`
+	HostingFile          string            `json:"hosting_file"`
+	AnonymousIPDBPath    string            `json:"anonymous_ip_db_path"`
+	HostingASNFile       string            `json:"hosting_asn_file"`
+	ASNDBPath            string            `json:"asn_db_path"`
+	ConnectionTypeDBPath string            `json:"connection_type_db_path"`
+	TrustedSameASN       []string          `json:"trusted_same_asn"`
+	SuspiciousNetworks   []string          `json:"suspicious_networks"`
+	GeoDBWatchInterval   string            `json:"geodb_watch_interval"`
+	StorageDriver        string            `json:"storage_driver"`
+	StorageDSN           string            `json:"storage_dsn"`
+	SpeedThreshold       float64           `json:"speed_threshold"`
+	SpeedUnit            string            `json:"speed_unit"`
+	PolicyFile           string            `json:"policy_file"`
+	TLSCert              string            `json:"tls_cert"`
+	TLSKey               string            `json:"tls_key"`
+	TLSClientCA          string            `json:"tls_client_ca"`
+	TLSClientAuth        string            `json:"tls_client_auth"`
+	AuthFile             string            `json:"auth_file"`
+	AuditLog             string            `json:"audit_log"`
+	Retention            string            `json:"retention"`
+	TenantRetention      map[string]string `json:"tenant_retention"`
+	RetentionInterval    string            `json:"retention_interval"`
+	RetentionBatchSize   int               `json:"retention_batch_size"`
+}
+
+// NewConfig is an implementation to initialize a Config with the default values
+func NewConfig() *Config {
+	return &Config{
+		GeoProviders:         []string{GeoProviderMaxMind},
+		GeoCSVRadius:         100,
+		GeoDBPath:            "GeoLite2-City.mmdb",
+		GeoLocale:            "en",
+		GeoDBWatchInterval:   "1m",
+		AnonymousIPDBPath:    "GeoIP2-Anonymous-IP.mmdb",
+		ASNDBPath:            "GeoLite2-ASN.mmdb",
+		ConnectionTypeDBPath: "GeoIP2-Connection-Type.mmdb",
+		StorageDriver:        "sqlite3",
+		StorageDSN:           "./ipaccess.db",
+		SpeedThreshold:       500,
+		SpeedUnit:            "mph",
+		RetentionInterval:    "1h",
+		RetentionBatchSize:   1000,
+	}
+}
+
+// LoadConfig is an implementation to load a Config from the JSON file given by CONFIG_FILE and then from the environment variables, the latter takes precedence
+func LoadConfig() (*Config, error) {
+	config := NewConfig()
+
+	path := os.Getenv("CONFIG_FILE")
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(b, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config.GeoOverrideFile = getEnv("GEO_OVERRIDE_FILE", config.GeoOverrideFile)
+	if v := os.Getenv("GEO_PROVIDERS"); v != "" {
+		config.GeoProviders = []string{}
+		for _, name := range strings.Split(v, ",") {
+			config.GeoProviders = append(config.GeoProviders, strings.TrimSpace(name))
+		}
+	}
+	config.GeoStaticFile = getEnv("GEO_STATIC_FILE", config.GeoStaticFile)
+	config.GeoCSVFile = getEnv("GEO_CSV_FILE", config.GeoCSVFile)
+	if v := os.Getenv("GEO_CSV_RADIUS"); v != "" {
+		radius, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		config.GeoCSVRadius = radius
+	}
+	config.GeoDBPath = getEnv("GEODB_PATH", config.GeoDBPath)
+	config.GeoLocale = getEnv("GEO_LOCALE", config.GeoLocale)
+	config.GeoDBWatchInterval = getEnv("GEODB_WATCH_INTERVAL", config.GeoDBWatchInterval)
+	config.TorExitFile = getEnv("TOR_EXIT_FILE", config.TorExitFile)
+	config.HostingFile = getEnv("HOSTING_FILE", config.HostingFile)
+	config.AnonymousIPDBPath = getEnv("ANONYMOUS_IP_DB_PATH", config.AnonymousIPDBPath)
+	config.HostingASNFile = getEnv("HOSTING_ASN_FILE", config.HostingASNFile)
+	config.ASNDBPath = getEnv("ASN_DB_PATH", config.ASNDBPath)
+	config.ConnectionTypeDBPath = getEnv("CONNECTION_TYPE_DB_PATH", config.ConnectionTypeDBPath)
+	if v := os.Getenv("TRUSTED_SAME_ASN"); v != "" {
+		config.TrustedSameASN = []string{}
+		for _, connectionType := range strings.Split(v, ",") {
+			config.TrustedSameASN = append(config.TrustedSameASN, strings.TrimSpace(connectionType))
+		}
+	}
+	if v := os.Getenv("SUSPICIOUS_NETWORKS"); v != "" {
+		config.SuspiciousNetworks = []string{}
+		for _, kind := range strings.Split(v, ",") {
+			config.SuspiciousNetworks = append(config.SuspiciousNetworks, strings.TrimSpace(kind))
+		}
+	}
+	config.StorageDriver = getEnv("STORAGE_DRIVER", config.StorageDriver)
+	config.StorageDSN = getEnv("STORAGE_DSN", config.StorageDSN)
+	config.SpeedUnit = getEnv("SPEED_UNIT", config.SpeedUnit)
+	config.PolicyFile = getEnv("POLICY_FILE", config.PolicyFile)
+	config.TLSCert = getEnv("TLS_CERT", config.TLSCert)
+	config.TLSKey = getEnv("TLS_KEY", config.TLSKey)
+	config.TLSClientCA = getEnv("TLS_CLIENT_CA", config.TLSClientCA)
+	config.TLSClientAuth = getEnv("TLS_CLIENT_AUTH", config.TLSClientAuth)
+	config.AuthFile = getEnv("AUTH_FILE", config.AuthFile)
+	config.AuditLog = getEnv("AUDIT_LOG", config.AuditLog)
+	config.Retention = getEnv("RETENTION", config.Retention)
+	config.RetentionInterval = getEnv("RETENTION_INTERVAL", config.RetentionInterval)
+	if v := os.Getenv("TENANT_RETENTION"); v != "" {
+		config.TenantRetention = map[string]string{}
+		for _, kv := range strings.Split(v, ",") {
+			i := strings.Index(kv, "=")
+			if i < 0 {
+				return nil, fmt.Errorf("invalid TENANT_RETENTION: %s", kv)
+			}
+			config.TenantRetention[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+		}
+	}
+	if v := os.Getenv("RETENTION_BATCH_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		config.RetentionBatchSize = size
+	}
+	if v := os.Getenv("SPEED_THRESHOLD"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, err
+		}
+		config.SpeedThreshold = threshold
+	}
+
+	return config, nil
+}
+
+// HasGeoProvider is an implementation to tell whether the geolocation provider of the name is configured
+func (config *Config) HasGeoProvider(name string) bool {
+	for _, p := range config.GeoProviders {
+		if p == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getEnv(key string, defaultValue string) string {
+	v := os.Getenv(key)
+	if v != "" {
+		return v
+	}
+
+	return defaultValue
+}
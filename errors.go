@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+// Error codes of ServiceError, which are stable for the clients to tell the failures apart regardless of the message
+const (
+	ErrorCodeInvalidRequest     = "INVALID_REQUEST"
+	ErrorCodeGeoNotFound        = "GEO_NOT_FOUND"
+	ErrorCodeGeoUnresolvable    = "GEO_UNRESOLVABLE"
+	ErrorCodeGeoUnavailable     = "GEO_UNAVAILABLE"
+	ErrorCodeDuplicateEvent     = "DUPLICATE_EVENT"
+	ErrorCodeEventNotFound      = "EVENT_NOT_FOUND"
+	ErrorCodePolicyNotFound     = "POLICY_NOT_FOUND"
+	ErrorCodeStorageUnavailable = "STORAGE_UNAVAILABLE"
+)
+
+// ServiceError is an error of the api logic carrying the http status and the stable error code of its response
+type ServiceError struct {
+	supermandetector.ServiceError
+}
+
+// Error is an implementation to get the message of the ServiceError with its http status
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+// NewServiceError is an implementation to classify the error into the http status and the error code of a ServiceError,
+// falling back to the given ones for the errors not known to be caused by the request
+func NewServiceError(err error, message string, status int, errorCode string) *ServiceError {
+	switch {
+	case errors.Is(err, ErrInvalidIpAddress):
+		status, errorCode = http.StatusBadRequest, ErrorCodeInvalidRequest
+	case errors.Is(err, ErrGeoNotFound):
+		status, errorCode = http.StatusNotFound, ErrorCodeGeoNotFound
+	case errors.Is(err, ErrGeoUnresolvable):
+		status, errorCode = http.StatusUnprocessableEntity, ErrorCodeGeoUnresolvable
+	case errors.Is(err, ErrDuplicateEvent), errors.Is(err, ErrEventConflict):
+		status, errorCode = http.StatusConflict, ErrorCodeDuplicateEvent
+	}
+
+	return &ServiceError{supermandetector.ServiceError{
+		Code:      int32(status),
+		ErrorCode: errorCode,
+		Message:   message,
+	}}
+}
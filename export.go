@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+// WriteUserDataExportCSV is an implementation to write the records of the user data export as CSV with a header row, one record per row
+func WriteUserDataExportCSV(w io.Writer, export *supermandetector.UserDataExport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"username", "timestamp_ms", "event_uuid", "ip_address", "lat", "lon", "radius", "principal", "provider", "label", "is_tor", "is_anonymizer", "is_hosting", "asn", "as_organization", "connection_type", "country_code", "subdivision", "city", "time_zone"})
+	for _, r := range export.Records {
+		cw.Write([]string{
+			r.Username,
+			strconv.FormatInt(r.Timestamp_ms, 10),
+			r.Event_uuid,
+			string(r.Ip_address),
+			strconv.FormatFloat(r.Lat, 'f', -1, 64),
+			strconv.FormatFloat(r.Lon, 'f', -1, 64),
+			strconv.FormatInt(int64(r.Radius), 10),
+			r.Principal,
+			r.Provider,
+			r.Label,
+			formatOptionalBool(r.IsTor),
+			formatOptionalBool(r.IsAnonymizer),
+			formatOptionalBool(r.IsHosting),
+			formatOptionalInt64(r.Asn),
+			r.AsOrganization,
+			r.ConnectionType,
+			r.CountryCode,
+			r.Subdivision,
+			r.City,
+			r.TimeZone,
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// formatOptionalBool formats an optional Bool, empty if it is not set
+func formatOptionalBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+
+	return strconv.FormatBool(*b)
+}
+
+// formatOptionalInt64 formats an optional Int64, empty if it is not set
+func formatOptionalInt64(n *int64) string {
+	if n == nil {
+		return ""
+	}
+
+	return strconv.FormatInt(*n, 10)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// GeoDB is an implementation to hold a MaxMind database, e.g. GeoLite2 City, which is reloaded from the file once it is updated.
+// The reader is swapped atomically, and the old one is closed after the lookups in flight on it finish.
+type GeoDB struct {
+	mu       sync.RWMutex
+	path     string
+	reader   *geoip2.Reader
+	modTime  time.Time
+	loadedAt time.Time
+	lastErr  error
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// OpenGeoDB is an implementation to open the MaxMind database at the path
+func OpenGeoDB(path string) (*GeoDB, error) {
+	db := &GeoDB{path: path}
+	err := db.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// openGeoDBIfPresent opens the MaxMind database at the path, nil if the path is empty or the file is not present
+func openGeoDBIfPresent(path string) (*GeoDB, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		log.Printf("No MaxMind database at %s, Error:%v\n", path, err)
+		return nil, nil
+	}
+
+	return OpenGeoDB(path)
+}
+
+// City is an implementation to look up the city of the ip address in the current database
+func (db *GeoDB) City(ip net.IP) (*geoip2.City, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.reader.City(ip)
+}
+
+// AnonymousIP is an implementation to look up whether the ip address is of an anonymizer in the current database
+func (db *GeoDB) AnonymousIP(ip net.IP) (*geoip2.AnonymousIP, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.reader.AnonymousIP(ip)
+}
+
+// ASN is an implementation to look up the autonomous system of the ip address in the current database
+func (db *GeoDB) ASN(ip net.IP) (*geoip2.ASN, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.reader.ASN(ip)
+}
+
+// ConnectionType is an implementation to look up the connection type of the ip address in the current database
+func (db *GeoDB) ConnectionType(ip net.IP) (*geoip2.ConnectionType, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.reader.ConnectionType(ip)
+}
+
+// Reload is an implementation to open the database file again and to swap the reader with it.
+// The current reader is kept when the file fails to open, e.g. while it is being replaced.
+func (db *GeoDB) Reload() error {
+	info, err := os.Stat(db.path)
+	if err == nil {
+		var reader *geoip2.Reader
+		reader, err = geoip2.Open(db.path)
+		if err == nil {
+			db.mu.Lock()
+			old := db.reader
+			db.reader = reader
+			db.modTime = info.ModTime()
+			db.loadedAt = time.Now()
+			db.lastErr = nil
+			db.mu.Unlock()
+
+			if old != nil {
+				old.Close()
+			}
+			log.Printf("Loaded %s database %s built at %v\n", reader.Metadata().DatabaseType, db.path, time.Unix(int64(reader.Metadata().BuildEpoch), 0).UTC())
+			return nil
+		}
+	}
+
+	db.mu.Lock()
+	if info != nil {
+		// not to retry the broken file until it is modified again
+		db.modTime = info.ModTime()
+	}
+	db.lastErr = err
+	db.mu.Unlock()
+	log.Printf("Failed to load MaxMind database %s, Error:%v\n", db.path, err)
+
+	return err
+}
+
+// ReloadIfModified is an implementation to reload the database when the modification time of the file changes
+func (db *GeoDB) ReloadIfModified() error {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return err
+	}
+
+	db.mu.RLock()
+	modified := !info.ModTime().Equal(db.modTime)
+	db.mu.RUnlock()
+	if !modified {
+		return nil
+	}
+
+	return db.Reload()
+}
+
+// Watch is an implementation to check every interval whether the file is modified to reload it, until the database is closed
+func (db *GeoDB) Watch(interval time.Duration) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.stop != nil {
+		return
+	}
+	db.stop = make(chan struct{})
+	db.done = make(chan struct{})
+	go func() {
+		defer close(db.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-db.stop:
+				return
+			case <-ticker.C:
+				db.ReloadIfModified()
+			}
+		}
+	}()
+}
+
+// Status is an implementation to get the metadata of the database loaded and the error of the last reload
+func (db *GeoDB) Status() *supermandetector.GeoDBStatus {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	metadata := db.reader.Metadata()
+	status := supermandetector.NewGeoDBStatus(&supermandetector.GeoDBStatus{
+		Path:         db.path,
+		DatabaseType: metadata.DatabaseType,
+		BuildEpoch:   int64(metadata.BuildEpoch),
+		LoadedAt:     rdl.Timestamp{Time: db.loadedAt.UTC()},
+	})
+	if db.lastErr != nil {
+		status.LastError = db.lastErr.Error()
+	}
+
+	return status
+}
+
+// Close is an implementation to stop watching the file and to close the reader
+func (db *GeoDB) Close() error {
+	db.mu.Lock()
+	stop, done := db.stop, db.done
+	db.stop = nil
+	db.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.reader.Close()
+}
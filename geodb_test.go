@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGeoDB(t *testing.T) {
+	type test struct {
+		name      string
+		path      string
+		checkFunc func(*GeoDB, string) error
+		wantErr   bool
+	}
+	src, err := ioutil.ReadFile("GeoLite2-City.mmdb")
+	if err != nil {
+		t.Skipf("GeoLite2 City database is not found, error: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "geodb")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []test{
+		{
+			name: "Check reload once the file is updated",
+			path: filepath.Join(dir, "updated.mmdb"),
+			checkFunc: func(db *GeoDB, path string) error {
+				loadedAt := db.Status().LoadedAt
+				err := db.ReloadIfModified()
+				if err != nil {
+					return err
+				}
+				if db.Status().LoadedAt != loadedAt {
+					return fmt.Errorf("reloaded without modification")
+				}
+
+				modTime := time.Now().Add(time.Minute)
+				os.Chtimes(path, modTime, modTime)
+				err = db.ReloadIfModified()
+				if err != nil {
+					return err
+				}
+				if db.Status().LoadedAt == loadedAt {
+					return fmt.Errorf("not reloaded after modification")
+				}
+				return nil
+			},
+		},
+		{
+			name: "Check the current database kept when the file is broken",
+			path: filepath.Join(dir, "broken.mmdb"),
+			checkFunc: func(db *GeoDB, path string) error {
+				want := db.Status().BuildEpoch
+				ioutil.WriteFile(path, []byte("broken"), 0644)
+				modTime := time.Now().Add(time.Minute)
+				os.Chtimes(path, modTime, modTime)
+				if err := db.ReloadIfModified(); err == nil {
+					return fmt.Errorf("got nil error, want an error of the broken file")
+				}
+
+				status := db.Status()
+				if status.BuildEpoch != want || status.LastError == "" {
+					return fmt.Errorf("status got: %+v, want the build epoch %d with the last error", status, want)
+				}
+				// the broken file is not retried until it is modified again
+				if err := db.ReloadIfModified(); err != nil {
+					return fmt.Errorf("retried the broken file, error: %v", err)
+				}
+
+				ioutil.WriteFile(path, src, 0644)
+				if err := db.Reload(); err != nil {
+					return err
+				}
+				if status := db.Status(); status.LastError != "" {
+					return fmt.Errorf("last error got: %s, want: empty", status.LastError)
+				}
+				return nil
+			},
+		},
+		{
+			name:    "Check missing file",
+			path:    filepath.Join(dir, "missing.mmdb"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.wantErr {
+				err := ioutil.WriteFile(tt.path, src, 0644)
+				if err != nil {
+					t.Errorf("failed to copy the database, error: %v", err)
+					return
+				}
+			}
+
+			db, err := OpenGeoDB(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got nil error, want an error of the missing file")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("failed to open, error: %v", err)
+				return
+			}
+			defer db.Close()
+
+			if tt.checkFunc != nil {
+				err = tt.checkFunc(db, tt.path)
+				if err != nil {
+					t.Errorf("compare check failed, err: %v", err)
+				}
+			}
+		})
+	}
+}
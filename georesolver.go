@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+const (
+	// GeoProviderOverride is the name of the provider of the corporate override table, asked before any other providers
+	GeoProviderOverride = "override"
+	// GeoProviderStatic is the name of the provider of the static override table
+	GeoProviderStatic = "static"
+	// GeoProviderCSV is the name of the provider of the CSV range file
+	GeoProviderCSV = "csv"
+	// GeoProviderMaxMind is the name of the provider of the GeoLite2 City database
+	GeoProviderMaxMind = "maxmind"
+)
+
+// GeoResolver is an interface to locate an ip address.
+// A resolver returns nil without an error when it has no confident answer, so that the next resolver in the chain is asked.
+type GeoResolver interface {
+	Name() string
+	Resolve(ip net.IP) (*supermandetector.CurrentGeo, error)
+}
+
+// GeoResolverChain is an implementation to ask the resolvers in the order of the priority, the first confident answer wins
+type GeoResolverChain []GeoResolver
+
+// Providers is an implementation to get the names of the resolvers in the order of the priority
+func (chain GeoResolverChain) Providers() []string {
+	names := make([]string, len(chain))
+	for i, r := range chain {
+		names[i] = r.Name()
+	}
+
+	return names
+}
+
+// Resolve is an implementation to locate the ip address by the first resolver answering it, and to record the resolver as the provider.
+// An error of a resolver does not stop the chain, and it is returned only when no resolver answers.
+func (chain GeoResolverChain) Resolve(ip net.IP) (*supermandetector.CurrentGeo, error) {
+	var lastErr error
+	for _, r := range chain {
+		geo, err := r.Resolve(ip)
+		if err != nil {
+			log.Printf("Failed to resolve %s by %s, Error:%v\n", ip, r.Name(), err)
+			lastErr = err
+			continue
+		}
+		if geo != nil {
+			geo.Provider = r.Name()
+			return geo, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrGeoNotFound, ip)
+}
+
+// Reload is an implementation to reload the resolvers of the chain which load their data from the files
+func (chain GeoResolverChain) Reload() error {
+	var err error
+	for _, r := range chain {
+		if reloadable, ok := r.(interface{ Reload() error }); ok {
+			if e := reloadable.Reload(); err == nil {
+				err = e
+			}
+		}
+	}
+
+	return err
+}
+
+// NewGeoResolverChain is an implementation to compose the resolvers of the providers of the configuration in the order given
+func NewGeoResolverChain(config *Config, geodb *GeoDB) (GeoResolverChain, error) {
+	if len(config.GeoProviders) == 0 {
+		return nil, fmt.Errorf("no geo provider is configured")
+	}
+
+	chain := GeoResolverChain{}
+	for _, name := range config.GeoProviders {
+		switch name {
+		case GeoProviderStatic:
+			if config.GeoStaticFile == "" {
+				return nil, fmt.Errorf("geo provider %s requires geo_static_file", name)
+			}
+			r, err := LoadStaticGeoResolver(name, config.GeoStaticFile)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, r)
+		case GeoProviderCSV:
+			if config.GeoCSVFile == "" {
+				return nil, fmt.Errorf("geo provider %s requires geo_csv_file", name)
+			}
+			r, err := LoadCSVGeoResolver(config.GeoCSVFile, int32(config.GeoCSVRadius))
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, r)
+		case GeoProviderMaxMind:
+			chain = append(chain, &MaxMindGeoResolver{db: geodb, locale: config.GeoLocale})
+		default:
+			return nil, fmt.Errorf("unknown geo provider: %s", name)
+		}
+	}
+
+	return chain, nil
+}
+
+// MaxMindGeoResolver is an implementation to locate an ip address by GeoLite2 City database,
+// the names of the places are of the locale, or in English if the database has no name of it
+type MaxMindGeoResolver struct {
+	db     *GeoDB
+	locale string
+}
+
+// Name is an implementation to get the name of the provider
+func (r *MaxMindGeoResolver) Name() string {
+	return GeoProviderMaxMind
+}
+
+// Resolve is an implementation to locate the ip address by GeoLite2 City database
+func (r *MaxMindGeoResolver) Resolve(ip net.IP) (*supermandetector.CurrentGeo, error) {
+	city, err := r.db.City(ip)
+	if err != nil {
+		return nil, err
+	}
+	// a record missing from the database is decoded into the zero value
+	if city.Location.Latitude == 0 && city.Location.Longitude == 0 && city.Location.AccuracyRadius == 0 {
+		return nil, nil
+	}
+
+	geo := supermandetector.NewCurrentGeo(&supermandetector.CurrentGeo{
+		Lat:         float64(city.Location.Latitude),
+		Lon:         float64(city.Location.Longitude),
+		Radius:      int32(city.Location.AccuracyRadius),
+		CountryCode: city.Country.IsoCode,
+		City:        r.localName(city.City.Names),
+		TimeZone:    city.Location.TimeZone,
+	})
+	if len(city.Subdivisions) > 0 {
+		geo.Subdivision = r.localName(city.Subdivisions[0].Names)
+	}
+
+	return geo, nil
+}
+
+// localName picks the name of the locale out of the names of a place, or the English one if it has no name of the locale
+func (r *MaxMindGeoResolver) localName(names map[string]string) string {
+	if name, ok := names[r.locale]; ok {
+		return name
+	}
+
+	return names["en"]
+}
+
+// geoRange is a range of the ip addresses located at the same place, the addresses are in 16-byte form to be compared alike
+type geoRange struct {
+	start  net.IP
+	end    net.IP
+	lat    float64
+	lon    float64
+	radius int32
+}
+
+// CSVGeoResolver is an implementation to locate an ip address by a CSV file of the ranges of the ip addresses,
+// such as DB-IP City Lite or IP2Location DB5
+type CSVGeoResolver struct {
+	ranges []geoRange
+}
+
+// LoadCSVGeoResolver is an implementation to load the ranges of a CSV file with the accuracy radius given to all of them.
+// Each line starts with the first and the last ip address of a range, either in text or as a decimal number of an IPv4 address,
+// and ends with its latitude and longitude. A first line whose first columns are not ip addresses, e.g. a header, is skipped.
+// The decimal numbers may as well be of IPv6 addresses, as IP2Location writes in its IPv6 database.
+// A range located at 0,0, which IP2Location writes for an unknown location, is not loaded.
+func LoadCSVGeoResolver(path string, radius int32) (*CSVGeoResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	ranges := []geoRange{}
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		var start, end net.IP
+		if len(row) >= 2 {
+			start, end = parseRangeIps(row[0], row[1])
+		}
+		if start == nil && line == 1 {
+			continue
+		}
+		if len(row) < 4 {
+			return nil, fmt.Errorf("%s:%d: too few columns", path, line)
+		}
+		if start == nil || bytes.Compare(start, end) > 0 {
+			return nil, fmt.Errorf("%s:%d: invalid range %s-%s", path, line, row[0], row[1])
+		}
+		lat, err := strconv.ParseFloat(row[len(row)-2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid latitude %s", path, line, row[len(row)-2])
+		}
+		lon, err := strconv.ParseFloat(row[len(row)-1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid longitude %s", path, line, row[len(row)-1])
+		}
+		// a range of unknown location, e.g. "-" of IP2Location, is left to the next provider
+		if lat == 0 && lon == 0 {
+			continue
+		}
+		ranges = append(ranges, geoRange{start: start, end: end, lat: lat, lon: lon, radius: radius})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+
+	return &CSVGeoResolver{ranges: ranges}, nil
+}
+
+// parseRangeIps parses the first and the last ip address of a range in text, or in decimal numbers as IP2Location writes, into 16-byte form.
+// The decimal numbers are of IPv4 addresses unless the last one is beyond them, as in the IPv6 database of IP2Location.
+func parseRangeIps(first string, last string) (net.IP, net.IP) {
+	start, end := net.ParseIP(first), net.ParseIP(last)
+	if start != nil && end != nil {
+		return start.To16(), end.To16()
+	}
+	s, ok := new(big.Int).SetString(first, 10)
+	if !ok || s.Sign() < 0 {
+		return nil, nil
+	}
+	e, ok := new(big.Int).SetString(last, 10)
+	if !ok || e.BitLen() > 128 || s.Cmp(e) > 0 {
+		return nil, nil
+	}
+	if e.BitLen() <= 32 {
+		return decimalIPv4(s.Uint64()), decimalIPv4(e.Uint64())
+	}
+
+	return net.IP(s.FillBytes(make([]byte, net.IPv6len))), net.IP(e.FillBytes(make([]byte, net.IPv6len)))
+}
+
+// decimalIPv4 converts a decimal number of an IPv4 address into 16-byte form
+func decimalIPv4(n uint64) net.IP {
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)).To16()
+}
+
+// Name is an implementation to get the name of the provider
+func (r *CSVGeoResolver) Name() string {
+	return GeoProviderCSV
+}
+
+// Resolve is an implementation to locate the ip address by the range containing it
+func (r *CSVGeoResolver) Resolve(ip net.IP) (*supermandetector.CurrentGeo, error) {
+	ip = ip.To16()
+	// the last range starting at or before the ip address
+	i := sort.Search(len(r.ranges), func(i int) bool {
+		return bytes.Compare(r.ranges[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, r.ranges[i].end) > 0 {
+		return nil, nil
+	}
+
+	return supermandetector.NewCurrentGeo(&supermandetector.CurrentGeo{
+		Lat:    r.ranges[i].lat,
+		Lon:    r.ranges[i].lon,
+		Radius: r.ranges[i].radius,
+	}), nil
+}
+
+// StaticGeoEntry is a location given to the ip addresses of a CIDR in the static override table, with a label to tell it, e.g. an office
+type StaticGeoEntry struct {
+	CIDR   string  `json:"cidr"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Radius int32   `json:"radius"`
+	Label  string  `json:"label,omitempty"`
+}
+
+// staticGeoEntry is a StaticGeoEntry with its network parsed
+type staticGeoEntry struct {
+	network *net.IPNet
+	geo     StaticGeoEntry
+}
+
+// StaticGeoResolver is an implementation to locate an ip address by the static override table, the most specific CIDR wins.
+// The table loaded from a file is reloaded at runtime, and the current one is kept when the file fails to load.
+type StaticGeoResolver struct {
+	name     string
+	path     string
+	mu       sync.RWMutex
+	entries  []staticGeoEntry
+	loadedAt time.Time
+	lastErr  error
+}
+
+// LoadStaticGeoResolver is an implementation to load the static override table of the provider name from a JSON file of the list of StaticGeoEntry
+func LoadStaticGeoResolver(name string, path string) (*StaticGeoResolver, error) {
+	r := &StaticGeoResolver{name: name, path: path}
+	err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// NewStaticGeoResolver is an implementation to initialize a StaticGeoResolver of the provider name with the entries
+func NewStaticGeoResolver(name string, list []StaticGeoEntry) (*StaticGeoResolver, error) {
+	entries, err := parseStaticGeoEntries(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaticGeoResolver{name: name, entries: entries, loadedAt: time.Now()}, nil
+}
+
+// parseStaticGeoEntries parses the CIDRs of the entries, and sorts them from the most specific
+func parseStaticGeoEntries(list []StaticGeoEntry) ([]staticGeoEntry, error) {
+	entries := make([]staticGeoEntry, 0, len(list))
+	for _, geo := range list {
+		_, network, err := net.ParseCIDR(geo.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, staticGeoEntry{network: network, geo: geo})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		oi, _ := entries[i].network.Mask.Size()
+		oj, _ := entries[j].network.Mask.Size()
+		return oi > oj
+	})
+
+	return entries, nil
+}
+
+// Reload is an implementation to load the table from the file again and to swap the current one with it
+func (r *StaticGeoResolver) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+
+	entries, err := func() ([]staticGeoEntry, error) {
+		b, err := ioutil.ReadFile(r.path)
+		if err != nil {
+			return nil, err
+		}
+		list := []StaticGeoEntry{}
+		err = json.Unmarshal(b, &list)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", r.path, err)
+		}
+		return parseStaticGeoEntries(list)
+	}()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastErr = err
+	if err != nil {
+		log.Printf("Failed to load %s geolocations %s, Error:%v\n", r.name, r.path, err)
+		return err
+	}
+	r.entries = entries
+	r.loadedAt = time.Now()
+	log.Printf("Loaded %d %s geolocations from %s\n", len(entries), r.name, r.path)
+
+	return nil
+}
+
+// Name is an implementation to get the name of the provider
+func (r *StaticGeoResolver) Name() string {
+	return r.name
+}
+
+// Resolve is an implementation to locate the ip address by the most specific CIDR containing it
+func (r *StaticGeoResolver) Resolve(ip net.IP) (*supermandetector.CurrentGeo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.network.Contains(ip) {
+			return supermandetector.NewCurrentGeo(&supermandetector.CurrentGeo{
+				Lat:    e.geo.Lat,
+				Lon:    e.geo.Lon,
+				Radius: e.geo.Radius,
+				Label:  e.geo.Label,
+			}), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Status is an implementation to get the number of the entries of the table loaded and the error of the last reload
+func (r *StaticGeoResolver) Status() *supermandetector.GeoOverrideStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := supermandetector.NewGeoOverrideStatus(&supermandetector.GeoOverrideStatus{
+		Path:     r.path,
+		Entries:  int32(len(r.entries)),
+		LoadedAt: rdl.Timestamp{Time: r.loadedAt.UTC()},
+	})
+	if r.lastErr != nil {
+		status.LastError = r.lastErr.Error()
+	}
+
+	return status
+}
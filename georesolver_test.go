@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+// errGeoResolver is a resolver failing every lookup
+type errGeoResolver struct{}
+
+func (r errGeoResolver) Name() string {
+	return "broken"
+}
+
+func (r errGeoResolver) Resolve(ip net.IP) (*supermandetector.CurrentGeo, error) {
+	return nil, fmt.Errorf("broken resolver")
+}
+
+func TestGeoResolverChain(t *testing.T) {
+	type args struct {
+		ip string
+	}
+	type test struct {
+		name    string
+		args    args
+		want    *supermandetector.CurrentGeo
+		wantErr error
+	}
+	dir, err := ioutil.TempDir("", "georesolver")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// DB-IP style with a header, and IP2Location style with decimal IPv4 addresses
+	csvFile := filepath.Join(dir, "ranges.csv")
+	err = ioutil.WriteFile(csvFile, []byte(`ip_start,ip_end,continent,country,stateprov,city,latitude,longitude
+206.81.252.0,206.81.252.255,NA,US,Maryland,Baltimore,39.2293,-76.6907
+2001:db8::,2001:db8::ffff,NA,US,Texas,Austin,30.3773,-97.71
+"1540337408","1540337663","US","United States of America","California","Los Angeles","34.0549","-118.2578"
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write the CSV file, error: %v", err)
+	}
+	csvResolver, err := LoadCSVGeoResolver(csvFile, 100)
+	if err != nil {
+		t.Fatalf("failed to load the CSV file, error: %v", err)
+	}
+	staticResolver, err := NewStaticGeoResolver(GeoProviderStatic, []StaticGeoEntry{
+		{CIDR: "206.81.0.0/16", Lat: 40.7128, Lon: -74.006, Radius: 1},
+		{CIDR: "206.81.252.0/28", Lat: 37.7749, Lon: -122.4194, Radius: 1, Label: "sf-vpn"},
+	})
+	if err != nil {
+		t.Fatalf("failed to instantiate the static resolver, error: %v", err)
+	}
+	chain := GeoResolverChain{errGeoResolver{}, staticResolver, csvResolver}
+
+	tests := []test{
+		{
+			name: "Check the most specific CIDR of the static table",
+			args: args{ip: "206.81.252.7"},
+			want: &supermandetector.CurrentGeo{Lat: 37.7749, Lon: -122.4194, Radius: 1, Provider: "static", Label: "sf-vpn"},
+		},
+		{
+			name: "Check the less specific CIDR of the static table",
+			args: args{ip: "206.81.1.1"},
+			want: &supermandetector.CurrentGeo{Lat: 40.7128, Lon: -74.006, Radius: 1, Provider: "static"},
+		},
+		{
+			name: "Check fallback to the CSV range in decimal form",
+			args: args{ip: "91.207.175.104"},
+			want: &supermandetector.CurrentGeo{Lat: 34.0549, Lon: -118.2578, Radius: 100, Provider: "csv"},
+		},
+		{
+			name: "Check fallback to the IPv6 CSV range",
+			args: args{ip: "2001:db8::1"},
+			want: &supermandetector.CurrentGeo{Lat: 30.3773, Lon: -97.71, Radius: 100, Provider: "csv"},
+		},
+		{
+			name:    "Check the error of the failed resolver when no resolver answers",
+			args:    args{ip: "24.242.71.20"},
+			wantErr: fmt.Errorf("broken resolver"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := chain.Resolve(net.ParseIP(tt.args.ip))
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %v, got: %v", tt.wantErr, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+
+	_, err = GeoResolverChain{staticResolver}.Resolve(net.ParseIP("24.242.71.20"))
+	if !reflect.DeepEqual(err, fmt.Errorf("%w: %s", ErrGeoNotFound, "24.242.71.20")) {
+		t.Errorf("error not the same, want: %v, got: %v", ErrGeoNotFound, err)
+	}
+}
+
+func TestLoadCSVGeoResolver(t *testing.T) {
+	type args struct {
+		csv string
+		ip  string
+	}
+	type test struct {
+		name    string
+		args    args
+		want    *supermandetector.CurrentGeo
+		wantErr string
+	}
+	dir, err := ioutil.TempDir("", "georesolver")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// IP2Location IPv6 style with decimal IPv6 addresses, the IPv4 addresses mapped into them
+	ip2locationIPv6 := `"0","281470681743359","-","-","-","-","0.000000","0.000000"
+"281472222080768","281472222081023","US","United States of America","California","Los Angeles","34.0549","-118.2578"
+"42540766411282592856903984951653826560","42540766411282592856903984951653892095","US","United States of America","Texas","Austin","30.3773","-97.71"
+`
+	tests := []test{
+		{
+			name: "Check the IPv4 range in decimal form of the IPv6 database",
+			args: args{csv: ip2locationIPv6, ip: "91.207.175.104"},
+			want: &supermandetector.CurrentGeo{Lat: 34.0549, Lon: -118.2578, Radius: 100},
+		},
+		{
+			name: "Check the IPv6 range in decimal form of the IPv6 database",
+			args: args{csv: ip2locationIPv6, ip: "2001:db8::1"},
+			want: &supermandetector.CurrentGeo{Lat: 30.3773, Lon: -97.71, Radius: 100},
+		},
+		{
+			name: "Check the range of unknown location not loaded",
+			args: args{csv: ip2locationIPv6, ip: "::1"},
+			want: nil,
+		},
+		{
+			name: "Check error of an invalid address after the header",
+			args: args{csv: `ip_start,ip_end,continent,country,stateprov,city,latitude,longitude
+206.81.252.0,206.81.252.255,NA,US,Maryland,Baltimore,39.2293,-76.6907
+206.81.253.x,206.81.253.255,NA,US,Maryland,Baltimore,39.2293,-76.6907
+`},
+			wantErr: "ranges.csv:3: invalid range 206.81.253.x-206.81.253.255",
+		},
+		{
+			name: "Check error of a decimal number beyond IPv6 addresses",
+			args: args{csv: `"281472222080768","281472222081023","US","United States of America","California","Los Angeles","34.0549","-118.2578"
+"281472222081024","340282366920938463463374607431768211456","-","-","-","-","0.000000","0.000000"
+`},
+			wantErr: "ranges.csv:2: invalid range 281472222081024-340282366920938463463374607431768211456",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csvFile := filepath.Join(dir, "ranges.csv")
+			err := ioutil.WriteFile(csvFile, []byte(tt.args.csv), 0644)
+			if err != nil {
+				t.Fatalf("failed to write the CSV file, error: %v", err)
+			}
+			resolver, err := LoadCSVGeoResolver(csvFile, 100)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+					t.Errorf("error not the same, want: %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load the CSV file, error: %v", err)
+			}
+			got, err := resolver.Resolve(net.ParseIP(tt.args.ip))
+			if err != nil {
+				t.Errorf("failed to resolve, error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGeoResolverChain(t *testing.T) {
+	type test struct {
+		name    string
+		config  *Config
+		want    []string
+		wantErr error
+	}
+	tests := []test{
+		{
+			name:    "Check unknown provider",
+			config:  &Config{GeoProviders: []string{"unknown"}},
+			wantErr: fmt.Errorf("unknown geo provider: unknown"),
+		},
+		{
+			name:    "Check static provider without file",
+			config:  &Config{GeoProviders: []string{GeoProviderStatic}},
+			wantErr: fmt.Errorf("geo provider static requires geo_static_file"),
+		},
+		{
+			name:    "Check no provider",
+			config:  &Config{},
+			wantErr: fmt.Errorf("no geo provider is configured"),
+		},
+		{
+			name:   "Check maxmind provider",
+			config: &Config{GeoProviders: []string{GeoProviderMaxMind}},
+			want:   []string{"maxmind"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewGeoResolverChain(tt.config, nil)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %v, got: %v", tt.wantErr, err)
+				return
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got.Providers(), tt.want) {
+				t.Errorf("got: %v, want: %v", got.Providers(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStaticGeoResolverReload(t *testing.T) {
+	type test struct {
+		name      string
+		content   string
+		want      *supermandetector.CurrentGeo
+		wantErr   bool
+		wantCount int32
+	}
+	dir, err := ioutil.TempDir("", "georesolver")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "overrides.json")
+	err = ioutil.WriteFile(path, []byte(`[{"cidr": "91.207.175.0/24", "lat": 34.0549, "lon": -118.2578, "radius": 5, "label": "la-office"}]`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write the override file, error: %v", err)
+	}
+	r, err := LoadStaticGeoResolver(GeoProviderOverride, path)
+	if err != nil {
+		t.Fatalf("failed to load the override file, error: %v", err)
+	}
+
+	tests := []test{
+		{
+			name:      "Check reload of the updated table",
+			content:   `[{"cidr": "91.207.175.0/24", "lat": 39.2293, "lon": -76.6907, "radius": 5, "label": "baltimore-vpn"}, {"cidr": "24.242.71.0/24", "lat": 30.3773, "lon": -97.71, "radius": 5}]`,
+			want:      &supermandetector.CurrentGeo{Lat: 39.2293, Lon: -76.6907, Radius: 5, Label: "baltimore-vpn"},
+			wantCount: 2,
+		},
+		{
+			name:      "Check the current table kept when the file is broken",
+			content:   `[{"cidr": "91.207.175.0/33"}]`,
+			want:      &supermandetector.CurrentGeo{Lat: 39.2293, Lon: -76.6907, Radius: 5, Label: "baltimore-vpn"},
+			wantErr:   true,
+			wantCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ioutil.WriteFile(path, []byte(tt.content), 0644)
+			if err != nil {
+				t.Errorf("failed to write the override file, error: %v", err)
+				return
+			}
+			err = r.Reload()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error got: %v, want error: %v", err, tt.wantErr)
+				return
+			}
+
+			got, err := r.Resolve(net.ParseIP("91.207.175.104"))
+			if err != nil {
+				t.Errorf("failed to resolve, error: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+			status := r.Status()
+			if status.Entries != tt.wantCount || (status.LastError != "") != tt.wantErr {
+				t.Errorf("status got: %+v, want entries: %d", status, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestMaxMindGeoResolverLocalName(t *testing.T) {
+	type args struct {
+		locale string
+	}
+	type test struct {
+		name string
+		args args
+		want string
+	}
+	names := map[string]string{"en": "Munich", "de": "München", "ja": "ミュンヘン"}
+	tests := []test{
+		{
+			name: "Check name of the locale",
+			args: args{locale: "de"},
+			want: "München",
+		},
+		{
+			name: "Check English name of the locale without a name",
+			args: args{locale: "fr"},
+			want: "Munich",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MaxMindGeoResolver{locale: tt.args.locale}
+			if got := r.localName(names); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
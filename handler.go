@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+// NewServiceHandler is an implementation to serve the api of impl at the base url by the handlers generated by rdl.
+// They know only rdl.ResourceError, so the ServiceErrors of the api logic are responded here with their http status and their stable error code,
+// and so is the user data export in CSV.
+func NewServiceHandler(impl *SupermanDetectorImpl, baseURL string) http.Handler {
+	router := supermandetector.Init(&serviceErrorAdaptor{impl}, baseURL, impl, impl.Authenticators()...)
+	base := ""
+	if u, err := url.Parse(strings.TrimSuffix(baseURL, "/")); err == nil {
+		base = u.Path
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isUserDataExportCSV(r, base) {
+			serveUserDataExportCSV(router, w, r)
+			return
+		}
+		router.ServeHTTP(&serviceErrorWriter{ResponseWriter: w}, r)
+	})
+}
+
+// serviceErrorWriter is a http.ResponseWriter which responds with the ServiceError handed over by serviceErrorAdaptor
+// in place of the rdl.ResourceError the generated handler writes for it
+type serviceErrorWriter struct {
+	http.ResponseWriter
+	err     *supermandetector.ServiceError
+	written bool
+}
+
+// WriteHeader is an implementation to write the ServiceError with its http status, if any, instead of the response of the generated handler
+func (w *serviceErrorWriter) WriteHeader(code int) {
+	if w.err == nil {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	b, _ := json.MarshalIndent(w.err, "", "  ")
+	w.ResponseWriter.Header().Set("Content-Type", "application/json")
+	w.ResponseWriter.WriteHeader(int(w.err.Code))
+	w.ResponseWriter.Write(append(b, '\n'))
+	w.written = true
+}
+
+// Write is an implementation to discard the body of the generated handler once the ServiceError is written
+func (w *serviceErrorWriter) Write(b []byte) (int, error) {
+	if w.written {
+		return len(b), nil
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// serviceErrorAdaptor is an implementation of the generated handler interface converting the ServiceErrors of the api logic
+// into rdl.ResourceError with their http status, and handing them over to serviceErrorWriter to be responded with their error code
+type serviceErrorAdaptor struct {
+	*SupermanDetectorImpl
+}
+
+// resourceError converts a ServiceError into rdl.ResourceError, and hands it over to the writer of the context
+func (a *serviceErrorAdaptor) resourceError(context *rdl.ResourceContext, err error) error {
+	e, ok := err.(*ServiceError)
+	if !ok {
+		return err
+	}
+	if w, ok := context.Writer.(*serviceErrorWriter); ok {
+		w.err = &e.ServiceError
+	}
+
+	return &rdl.ResourceError{Code: int(e.Code), Message: e.Message}
+}
+
+func (a *serviceErrorAdaptor) PostIpAccessRequest(context *rdl.ResourceContext, request *supermandetector.IpAccessRequest) (*supermandetector.IpAccessResponse, error) {
+	data, err := a.SupermanDetectorImpl.PostIpAccessRequest(context, request)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) PostIpAccessRequestV2(context *rdl.ResourceContext, request *supermandetector.IpAccessRequestV2) (*supermandetector.IpAccessResponse, error) {
+	data, err := a.SupermanDetectorImpl.PostIpAccessRequestV2(context, request)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) PostIpAccessBatchRequest(context *rdl.ResourceContext, batch *supermandetector.IpAccessBatchRequest) (*supermandetector.IpAccessBatchResponse, error) {
+	data, err := a.SupermanDetectorImpl.PostIpAccessBatchRequest(context, batch)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) EvaluateIpAccessRequest(context *rdl.ResourceContext, request *supermandetector.IpAccessRequestV2) (*supermandetector.IpAccessResponse, error) {
+	data, err := a.SupermanDetectorImpl.EvaluateIpAccessRequest(context, request)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) GetIpAccessEvent(context *rdl.ResourceContext, uuid string) (*supermandetector.IpAccessEvent, error) {
+	data, err := a.SupermanDetectorImpl.GetIpAccessEvent(context, uuid)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) GetUserIpAccessTimeline(context *rdl.ResourceContext, username string, from *int64, to *int64, limit int32, next string) (*supermandetector.IpAccessTimeline, error) {
+	data, err := a.SupermanDetectorImpl.GetUserIpAccessTimeline(context, username, from, to, limit, next)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) GetUserDataExport(context *rdl.ResourceContext, username string, format string) (*supermandetector.UserDataExport, error) {
+	data, err := a.SupermanDetectorImpl.GetUserDataExport(context, username, format)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) DeleteUserData(context *rdl.ResourceContext, username string) (*supermandetector.UserDataErasure, error) {
+	data, err := a.SupermanDetectorImpl.DeleteUserData(context, username)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) GetServiceStatus(context *rdl.ResourceContext) (*supermandetector.ServiceStatus, error) {
+	data, err := a.SupermanDetectorImpl.GetServiceStatus(context)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) GetRetentionStatus(context *rdl.ResourceContext) (*supermandetector.RetentionStatus, error) {
+	data, err := a.SupermanDetectorImpl.GetRetentionStatus(context)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) GetSpeedPolicies(context *rdl.ResourceContext) (*supermandetector.SpeedPolicies, error) {
+	data, err := a.SupermanDetectorImpl.GetSpeedPolicies(context)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) PutUserSpeedPolicy(context *rdl.ResourceContext, username string, policy *supermandetector.SpeedPolicy) (*supermandetector.SpeedPolicy, error) {
+	data, err := a.SupermanDetectorImpl.PutUserSpeedPolicy(context, username, policy)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) DeleteUserSpeedPolicy(context *rdl.ResourceContext, username string) error {
+	return a.resourceError(context, a.SupermanDetectorImpl.DeleteUserSpeedPolicy(context, username))
+}
+
+func (a *serviceErrorAdaptor) PutSpeedPolicyGroup(context *rdl.ResourceContext, name string, group *supermandetector.SpeedPolicyGroup) (*supermandetector.SpeedPolicyGroup, error) {
+	data, err := a.SupermanDetectorImpl.PutSpeedPolicyGroup(context, name, group)
+	return data, a.resourceError(context, err)
+}
+
+func (a *serviceErrorAdaptor) DeleteSpeedPolicyGroup(context *rdl.ResourceContext, name string) error {
+	return a.resourceError(context, a.SupermanDetectorImpl.DeleteSpeedPolicyGroup(context, name))
+}
+
+// isUserDataExportCSV tells whether the request is of the user data export in CSV
+func isUserDataExportCSV(r *http.Request, base string) bool {
+	if r.Method != http.MethodGet || r.URL.Query().Get("format") != "csv" {
+		return false
+	}
+	path := strings.TrimPrefix(r.URL.Path, base+"/users/")
+	if path == r.URL.Path || !strings.HasSuffix(path, "/export") {
+		return false
+	}
+
+	return !strings.Contains(strings.TrimSuffix(path, "/export"), "/")
+}
+
+// responseBuffer is a http.ResponseWriter keeping the response in memory
+type responseBuffer struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	b.code = code
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// serveUserDataExportCSV is an implementation to serve the user data export by the generated handler, authenticated and audited alike,
+// and to write it in CSV, or the error response as it is
+func serveUserDataExportCSV(router http.Handler, w http.ResponseWriter, r *http.Request) {
+	buf := &responseBuffer{header: http.Header{}, code: http.StatusOK}
+	router.ServeHTTP(&serviceErrorWriter{ResponseWriter: buf}, r)
+
+	export := &supermandetector.UserDataExport{}
+	if buf.code != http.StatusOK || json.Unmarshal(buf.body.Bytes(), export) != nil {
+		for k, v := range buf.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(buf.code)
+		w.Write(buf.body.Bytes())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	WriteUserDataExportCSV(w, export)
+}
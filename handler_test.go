@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceHandler(t *testing.T) {
+	type args struct {
+		method string
+		path   string
+		body   string
+	}
+	type test struct {
+		name            string
+		args            args
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}
+	dir, err := ioutil.TempDir("", "handler")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	static := filepath.Join(dir, "static.json")
+	ioutil.WriteFile(static, []byte(`[{"cidr": "206.81.252.0/24", "lat": 39.2293, "lon": -76.6907, "radius": 10}]`), 0644)
+	config := newTestConfig()
+	config.GeoProviders = []string{GeoProviderStatic}
+	config.GeoStaticFile = static
+	impl, err := NewSupermanDetectorImpl("http://0.0.0.0:80/", config)
+	if err != nil {
+		t.Fatalf("failed to instantiate, error: %v", err)
+	}
+	defer impl.Close()
+	var audit bytes.Buffer
+	impl.audit = &AuditLog{w: &audit, now: func() time.Time { return time.Unix(1514764800, 0) }}
+	handler := NewServiceHandler(impl, "http://0.0.0.0:80/")
+
+	tests := []test{
+		{
+			name:            "Check registration",
+			args:            args{method: "POST", path: "/v2", body: `{"username": "bob", "timestamp_ms": 1514764800000, "event_uuid": "85ad929a-db03-4bf4-9541-8f728fa12e41", "ip_address": "206.81.252.7"}`},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `"currentGeo"`,
+		},
+		{
+			name:            "Check http status and error code of a reused event uuid",
+			args:            args{method: "POST", path: "/v2", body: `{"username": "alice", "timestamp_ms": 1514764800000, "event_uuid": "85ad929a-db03-4bf4-9541-8f728fa12e41", "ip_address": "206.81.252.7"}`},
+			wantStatus:      http.StatusConflict,
+			wantContentType: "application/json",
+			wantBody:        `"errorCode": "DUPLICATE_EVENT"`,
+		},
+		{
+			name:            "Check http status and error code of an unknown event",
+			args:            args{method: "GET", path: "/events/85ad929a-db03-4bf4-9541-8f728fa12e40"},
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "application/json",
+			wantBody:        `"errorCode": "EVENT_NOT_FOUND"`,
+		},
+		{
+			name:            "Check user data export in CSV",
+			args:            args{method: "GET", path: "/users/bob/export?format=csv"},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody:        "bob,1514764800000,85ad929a-db03-4bf4-9541-8f728fa12e41,206.81.252.7,39.2293,-76.6907,10,",
+		},
+		{
+			name:            "Check user data export in JSON",
+			args:            args{method: "GET", path: "/users/bob/export"},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `"username": "bob"`,
+		},
+		{
+			name:            "Check http status and error code of an unknown export format",
+			args:            args{method: "GET", path: "/users/bob/export?format=xml"},
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: "application/json",
+			wantBody:        `"errorCode": "INVALID_REQUEST"`,
+		},
+		{
+			name:            "Check http status and error code of an invalid speed policy",
+			args:            args{method: "PUT", path: "/policies/users/bob", body: `{"threshold": -1, "unit": "MPH"}`},
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: "application/json",
+			wantBody:        `"errorCode": "INVALID_REQUEST"`,
+		},
+		{
+			name:            "Check http status and error code of an unknown speed policy group",
+			args:            args{method: "DELETE", path: "/policies/groups/airline-crew"},
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "application/json",
+			wantBody:        `"errorCode": "POLICY_NOT_FOUND"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.args.method, tt.args.path, strings.NewReader(tt.args.body)))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status got: %d, want: %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("content type got: %s, want: %s", got, tt.wantContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body got: %s, want to contain: %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"log"
+	"net"
 	"net/http"
 	"os"
-
-	"gitlab.com/cty3000/superman-detector/supermandetector"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func getPort() string {
@@ -25,22 +29,120 @@ func getEndPoint() string {
 	return "0.0.0.0:" + getPort()
 }
 
-func getUrl() string {
+func getUrl(secure bool) string {
 	p := os.Getenv("PROTOCOL")
 	if p != "" {
 		return p + "://" + getEndPoint() + "/"
 	}
+	if secure {
+		return "https://" + getEndPoint() + "/"
+	}
 
 	return "http://" + getEndPoint() + "/"
 }
 
+func getTimeout(key string, defaultValue time.Duration) time.Duration {
+	t := os.Getenv(key)
+	if t != "" {
+		d, err := time.ParseDuration(t)
+		if err == nil {
+			return d
+		}
+		log.Printf("Invalid %s: %v, using %v\n", key, err, defaultValue)
+	}
+
+	return defaultValue
+}
+
+func newServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         getEndPoint(),
+		Handler:      handler,
+		ReadTimeout:  getTimeout("READ_TIMEOUT", 10*time.Second),
+		WriteTimeout: getTimeout("WRITE_TIMEOUT", 10*time.Second),
+		IdleTimeout:  getTimeout("IDLE_TIMEOUT", 60*time.Second),
+	}
+}
+
+// serve is an implementation to serve on the listener until a signal is received on sigCh, and then to drain the in-flight requests.
+// It returns nil once drained, or the error the server stopped with.
+func serve(server *http.Server, listener net.Listener, secure bool, sigCh <-chan os.Signal) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if secure {
+			errCh <- server.ServeTLS(listener, "", "")
+		} else {
+			errCh <- server.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-sigCh:
+		log.Printf("Received %v, draining in-flight requests\n", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), getTimeout("SHUTDOWN_TIMEOUT", 30*time.Second))
+		defer cancel()
+		err := server.Shutdown(ctx)
+		if err != nil {
+			return err
+		}
+		if err = <-errCh; err != http.ErrServerClosed {
+			return err
+		}
+
+		return nil
+	}
+}
+
 func main() {
-	url := getUrl()
+	config, err := LoadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	secure := config.TLSCert != ""
+	url := getUrl(secure)
 
-	impl, err := NewSupermanDetectorImpl(url)
+	impl, err := NewSupermanDetectorImpl(url, config)
 	if err != nil {
 		panic(err)
 	}
+	impl.StartPurger()
+	impl.StartGeoDBWatcher()
 
-	http.Handle("/", supermandetector.Init(impl, url, impl))
+	server := newServer(NewServiceHandler(impl, url))
+	if secure {
+		server.TLSConfig, err = NewTLSConfig(config)
+		if err != nil {
+			impl.Close()
+			panic(err)
+		}
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		impl.Close()
+		panic(err)
+	}
+
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			log.Printf("Received SIGHUP, reloading GeoLite2 City database and geolocation overrides\n")
+			if err := impl.ReloadGeo(); err != nil {
+				log.Printf("Failed to reload GeoLite2 City database and geolocation overrides, Error:%v\n", err)
+			}
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	err = serve(server, listener, secure, sigCh)
+	impl.Close()
+	if err != nil {
+		log.Printf("Server stopped, Error:%v\n", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	type test struct {
+		name         string
+		closeFirst   bool
+		wantInFlight bool
+		wantErr      bool
+	}
+	tests := []test{
+		{
+			name:         "Check draining of an in-flight request on a signal",
+			wantInFlight: true,
+			wantErr:      false,
+		},
+		{
+			name:       "Check error of a server failing to serve",
+			closeFirst: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen, error: %v", err)
+			}
+			started := make(chan struct{})
+			server := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(200 * time.Millisecond)
+				w.Write([]byte("drained"))
+			}))
+			if tt.closeFirst {
+				listener.Close()
+			}
+
+			sigCh := make(chan os.Signal, 1)
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- serve(server, listener, false, sigCh)
+			}()
+
+			bodyCh := make(chan string, 1)
+			if tt.wantInFlight {
+				go func() {
+					resp, err := http.Get("http://" + listener.Addr().String() + "/")
+					if err != nil {
+						bodyCh <- err.Error()
+						return
+					}
+					defer resp.Body.Close()
+					b, _ := ioutil.ReadAll(resp.Body)
+					bodyCh <- string(b)
+				}()
+				<-started
+				sigCh <- syscall.SIGTERM
+			}
+
+			select {
+			case err = <-errCh:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("serve did not return")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("serve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantInFlight {
+				return
+			}
+			if body := <-bodyCh; body != "drained" {
+				t.Errorf("in-flight response got: %s, want: drained", body)
+			}
+			if _, err = http.Get("http://" + listener.Addr().String() + "/"); err == nil {
+				t.Errorf("request after shutdown got no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Migration is a versioned step to upgrade the schema of a sql database
+type Migration struct {
+	Version     int
+	Description string
+	// Up is the statement to apply per sql driver
+	Up map[string]string
+}
+
+// migrations is the ordered list of the schema migrations, append a new one to change the schema
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create ipaccess table",
+		Up: map[string]string{
+			"sqlite3": `
+			create table if not exists ipaccess (username text not null, unix_timestamp integer not null, event_uuid text not null primary key, ip_address text not null, lat real not null, lon real not null, radius not null);
+			create index if not exists ipaccess_username_unix_timestamp on ipaccess (username, unix_timestamp);
+			`,
+			"postgres": `
+			create table if not exists ipaccess (username text not null, unix_timestamp bigint not null, event_uuid text not null primary key, ip_address text not null, lat double precision not null, lon double precision not null, radius integer not null);
+			create index if not exists ipaccess_username_unix_timestamp on ipaccess (username, unix_timestamp);
+			`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "add principal to ipaccess",
+		Up: map[string]string{
+			"sqlite3":  `alter table ipaccess add column principal text not null default '';`,
+			"postgres": `alter table ipaccess add column principal text not null default '';`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "store timestamp of ipaccess in 64-bit milliseconds",
+		Up: map[string]string{
+			"sqlite3": `
+			create table ipaccess_v3 (username text not null, timestamp_ms integer not null, event_uuid text not null primary key, ip_address text not null, lat real not null, lon real not null, radius integer not null, principal text not null default '');
+			insert into ipaccess_v3 (username, timestamp_ms, event_uuid, ip_address, lat, lon, radius, principal) select username, unix_timestamp * 1000, event_uuid, ip_address, lat, lon, radius, principal from ipaccess;
+			drop table ipaccess;
+			alter table ipaccess_v3 rename to ipaccess;
+			create index ipaccess_username_timestamp_ms on ipaccess (username, timestamp_ms);
+			`,
+			"postgres": `
+			alter table ipaccess alter column unix_timestamp type bigint using unix_timestamp::bigint * 1000;
+			alter table ipaccess rename column unix_timestamp to timestamp_ms;
+			alter index ipaccess_username_unix_timestamp rename to ipaccess_username_timestamp_ms;
+			`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "index ipaccess by timestamp to purge expired records",
+		Up: map[string]string{
+			"sqlite3":  `create index if not exists ipaccess_timestamp_ms on ipaccess (timestamp_ms);`,
+			"postgres": `create index if not exists ipaccess_timestamp_ms on ipaccess (timestamp_ms);`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "add geolocation provider and label to ipaccess",
+		Up: map[string]string{
+			"sqlite3": `
+			alter table ipaccess add column provider text not null default '';
+			alter table ipaccess add column label text not null default '';
+			`,
+			"postgres": `
+			alter table ipaccess add column provider text not null default '';
+			alter table ipaccess add column label text not null default '';
+			`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "add network flags to ipaccess",
+		Up: map[string]string{
+			"sqlite3": `
+			alter table ipaccess add column is_tor integer;
+			alter table ipaccess add column is_anonymizer integer;
+			alter table ipaccess add column is_hosting integer;
+			`,
+			"postgres": `
+			alter table ipaccess add column is_tor boolean;
+			alter table ipaccess add column is_anonymizer boolean;
+			alter table ipaccess add column is_hosting boolean;
+			`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "add autonomous system and connection type to ipaccess",
+		Up: map[string]string{
+			"sqlite3": `
+			alter table ipaccess add column asn integer;
+			alter table ipaccess add column as_organization text not null default '';
+			alter table ipaccess add column connection_type text not null default '';
+			`,
+			"postgres": `
+			alter table ipaccess add column asn bigint;
+			alter table ipaccess add column as_organization text not null default '';
+			alter table ipaccess add column connection_type text not null default '';
+			`,
+		},
+	},
+	{
+		Version:     8,
+		Description: "add country, subdivision, city and time zone to ipaccess",
+		Up: map[string]string{
+			"sqlite3": `
+			alter table ipaccess add column country_code text not null default '';
+			alter table ipaccess add column subdivision text not null default '';
+			alter table ipaccess add column city text not null default '';
+			alter table ipaccess add column time_zone text not null default '';
+			`,
+			"postgres": `
+			alter table ipaccess add column country_code text not null default '';
+			alter table ipaccess add column subdivision text not null default '';
+			alter table ipaccess add column city text not null default '';
+			alter table ipaccess add column time_zone text not null default '';
+			`,
+		},
+	},
+}
+
+// LatestSchemaVersion is an implementation to get the version the migrations upgrade a database to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion is an implementation to get the current schema version of the database, 0 if nothing is applied
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("select coalesce(max(version), 0) from schema_version").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Migrate is an implementation to apply the pending migrations to the database in order
+func Migrate(db *sql.DB, driver string) error {
+	_, err := db.Exec("create table if not exists schema_version (version integer not null primary key, description text not null, applied_at bigint not null)")
+	if err != nil {
+		return err
+	}
+
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if current > LatestSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than the supported version %d", current, LatestSchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		stmt, ok := m.Up[driver]
+		if !ok {
+			return fmt.Errorf("migration %d does not support the storage driver: %s", m.Version, driver)
+		}
+
+		err = applyMigration(db, driver, m.Version, m.Description, stmt)
+		if err != nil {
+			log.Printf("%q: %s\n", err, stmt)
+			return fmt.Errorf("failed to apply migration %d (%s): %v", m.Version, m.Description, err)
+		}
+		log.Printf("Applied schema migration %d: %s\n", m.Version, m.Description)
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, driver string, version int, description string, stmt string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(stmt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(bindQuery(driver, "insert into schema_version(version, description, applied_at) values(?, ?, ?)"), version, description, time.Now().Unix())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMigrate(t *testing.T) {
+	type args struct {
+		path string
+	}
+	type test struct {
+		name       string
+		args       args
+		beforeFunc func(*sql.DB)
+		checkFunc  func(*sql.DB) error
+		afterFunc  func()
+		want       int
+		wantErr    error
+	}
+	tests := []test{
+		{
+			name: "Check fresh database",
+			args: args{
+				path: "./migration_test.db",
+			},
+			want: LatestSchemaVersion(),
+		},
+		{
+			name: "Check already migrated database",
+			args: args{
+				path: "./migration_test.db",
+			},
+			beforeFunc: func(db *sql.DB) {
+				Migrate(db, "sqlite3")
+			},
+			want: LatestSchemaVersion(),
+		},
+		{
+			name: "Check database of version 1",
+			args: args{
+				path: "./migration_test.db",
+			},
+			beforeFunc: func(db *sql.DB) {
+				db.Exec("create table schema_version (version integer not null primary key, description text not null, applied_at bigint not null)")
+				db.Exec(migrations[0].Up["sqlite3"])
+				db.Exec("insert into schema_version(version, description, applied_at) values(?, ?, ?)", 1, migrations[0].Description, 0)
+				db.Exec("insert into ipaccess(username, unix_timestamp, event_uuid, ip_address, lat, lon, radius) values(?, ?, ?, ?, ?, ?, ?)", "bob", 1514764800, "85ad929a-db03-4bf4-9541-8f728fa12e41", "206.81.252.7", 39.2293, -76.6907, 10)
+			},
+			checkFunc: func(db *sql.DB) error {
+				var timestampMs int64
+				var principal string
+				err := db.QueryRow("select timestamp_ms, principal from ipaccess where event_uuid = ?", "85ad929a-db03-4bf4-9541-8f728fa12e41").Scan(&timestampMs, &principal)
+				if err != nil {
+					return err
+				}
+				if timestampMs != 1514764800000 {
+					return fmt.Errorf("got timestamp_ms: %v, want: %v", timestampMs, 1514764800000)
+				}
+				if principal != "" {
+					return fmt.Errorf("got principal: %v, want empty", principal)
+				}
+				return nil
+			},
+			want: LatestSchemaVersion(),
+		},
+		{
+			name: "Check database newer than supported",
+			args: args{
+				path: "./migration_test.db",
+			},
+			beforeFunc: func(db *sql.DB) {
+				Migrate(db, "sqlite3")
+				db.Exec("insert into schema_version(version, description, applied_at) values(?, ?, ?)", LatestSchemaVersion()+1, "future", 0)
+			},
+			wantErr: fmt.Errorf("database schema version %d is newer than the supported version %d", LatestSchemaVersion()+1, LatestSchemaVersion()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(tt.args.path)
+			defer os.Remove(tt.args.path)
+			if tt.afterFunc != nil {
+				defer tt.afterFunc()
+			}
+
+			db, err := sql.Open("sqlite3", tt.args.path)
+			if err != nil {
+				t.Errorf("failed to open, error: %v", err)
+				return
+			}
+			defer db.Close()
+
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(db)
+			}
+
+			err = Migrate(db, "sqlite3")
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("failed to migrate, error: %v", err)
+				return
+			} else if tt.wantErr != nil {
+				if err == nil {
+					t.Errorf("got nil error, want: %v, got: %v", tt.wantErr, err)
+				} else if tt.wantErr.Error() != err.Error() {
+					t.Errorf("error not the same, want: %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			got, err := SchemaVersion(db)
+			if err != nil {
+				t.Errorf("failed to get schema version, error: %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+			if tt.checkFunc != nil {
+				if err := tt.checkFunc(db); err != nil {
+					t.Errorf("failed to check, error: %v", err)
+				}
+			}
+		})
+	}
+}
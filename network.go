@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// NetworkTor is the kind of the ip addresses of Tor exit nodes
+	NetworkTor = "tor"
+	// NetworkAnonymizer is the kind of the ip addresses of anonymizers, e.g. VPNs, public proxies or Tor exit nodes
+	NetworkAnonymizer = "anonymizer"
+	// NetworkHosting is the kind of the ip addresses of hosting providers, e.g. clouds
+	NetworkHosting = "hosting"
+)
+
+// NetworkFlags are the kinds of the network of an ip address, a flag is nil when no source of its kind is configured
+type NetworkFlags struct {
+	Tor        *bool
+	Anonymizer *bool
+	Hosting    *bool
+}
+
+// NetworkClassifier is an implementation to tell the kinds of the network of an ip address by the local lists,
+// the Tor exit list, the lists of the ranges and the autonomous systems of hosting providers and MaxMind Anonymous IP database
+type NetworkClassifier struct {
+	torPath        string
+	hostingPath    string
+	hostingASNPath string
+	anonymousIP    *GeoDB
+
+	mu         sync.RWMutex
+	tor        map[string]bool
+	hosting    []*net.IPNet
+	hostingASN map[int64]bool
+}
+
+// NewNetworkClassifier is an implementation to load the lists of the configuration, nil if no list is configured.
+// MaxMind Anonymous IP database is used only if the file is present.
+func NewNetworkClassifier(config *Config) (*NetworkClassifier, error) {
+	var err error
+	c := &NetworkClassifier{torPath: config.TorExitFile, hostingPath: config.HostingFile, hostingASNPath: config.HostingASNFile}
+	c.anonymousIP, err = openGeoDBIfPresent(config.AnonymousIPDBPath)
+	if err != nil {
+		return nil, err
+	}
+	if c.torPath == "" && c.hostingPath == "" && c.hostingASNPath == "" && c.anonymousIP == nil {
+		return nil, nil
+	}
+
+	err = c.Reload()
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Reload is an implementation to load the Tor exit list and the lists of the hosting providers from the files again.
+// The current lists are kept when a file fails to load.
+func (c *NetworkClassifier) Reload() error {
+	var err error
+	tor := map[string]bool{}
+	if c.torPath != "" {
+		tor, err = loadTorExitList(c.torPath)
+		if err != nil {
+			log.Printf("Failed to load Tor exit list %s, Error:%v\n", c.torPath, err)
+			return err
+		}
+	}
+	hosting := []*net.IPNet{}
+	if c.hostingPath != "" {
+		hosting, err = loadNetworkList(c.hostingPath)
+		if err != nil {
+			log.Printf("Failed to load hosting list %s, Error:%v\n", c.hostingPath, err)
+			return err
+		}
+	}
+	hostingASN := map[int64]bool{}
+	if c.hostingASNPath != "" {
+		hostingASN, err = loadASNList(c.hostingASNPath)
+		if err != nil {
+			log.Printf("Failed to load hosting ASN list %s, Error:%v\n", c.hostingASNPath, err)
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.tor = tor
+	c.hosting = hosting
+	c.hostingASN = hostingASN
+	c.mu.Unlock()
+	log.Printf("Loaded %d Tor exit nodes, %d hosting ranges and %d hosting ASNs\n", len(tor), len(hosting), len(hostingASN))
+
+	return nil
+}
+
+// loadTorExitList loads the ip addresses of a Tor exit list, either one address per line,
+// or the exit list of the Tor Project where the address follows ExitAddress
+func loadTorExitList(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tor := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		s := fields[0]
+		if s == "ExitAddress" && len(fields) > 1 {
+			s = fields[1]
+		} else if len(fields) > 1 {
+			// the other entries of the exit list of the Tor Project
+			continue
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("%s:%d: invalid ip address %s", path, line, s)
+		}
+		tor[ip.String()] = true
+	}
+
+	return tor, scanner.Err()
+}
+
+// loadNetworkList loads the CIDRs of a list, one CIDR or ip address per line
+func loadNetworkList(path string) ([]*net.IPNet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	networks := []*net.IPNet{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, scanner.Err()
+}
+
+// loadASNList loads the autonomous system numbers of a list, one number per line with or without the prefix AS
+func loadASNList(path string) (map[int64]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	asns := map[int64]bool{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		asn, err := strconv.ParseInt(strings.TrimPrefix(strings.ToUpper(fields[0]), "AS"), 10, 64)
+		if err != nil || asn <= 0 {
+			return nil, fmt.Errorf("%s:%d: invalid ASN %s", path, line, fields[0])
+		}
+		asns[asn] = true
+	}
+
+	return asns, scanner.Err()
+}
+
+// Classify is an implementation to tell the kinds of the network of the ip address in the autonomous system, nil if it is unknown.
+// A failure of MaxMind Anonymous IP database is logged, and the ip address is classified by the lists only.
+func (c *NetworkClassifier) Classify(ip net.IP, asn *int64) NetworkFlags {
+	flags := NetworkFlags{}
+	if c.torPath != "" || c.anonymousIP != nil {
+		flags.Tor, flags.Anonymizer = new(bool), new(bool)
+	}
+	if c.hostingPath != "" || c.hostingASNPath != "" || c.anonymousIP != nil {
+		flags.Hosting = new(bool)
+	}
+
+	c.mu.RLock()
+	if c.tor[ip.String()] {
+		*flags.Tor, *flags.Anonymizer = true, true
+	}
+	for _, network := range c.hosting {
+		if network.Contains(ip) {
+			*flags.Hosting = true
+			break
+		}
+	}
+	if asn != nil && c.hostingASN[*asn] {
+		*flags.Hosting = true
+	}
+	c.mu.RUnlock()
+
+	if c.anonymousIP != nil {
+		anonymous, err := c.anonymousIP.AnonymousIP(ip)
+		if err != nil {
+			log.Printf("Failed to get anonymous ip of %s, Error:%v\n", ip, err)
+			return flags
+		}
+		*flags.Tor = *flags.Tor || anonymous.IsTorExitNode
+		*flags.Anonymizer = *flags.Anonymizer || anonymous.IsAnonymous
+		*flags.Hosting = *flags.Hosting || anonymous.IsHostingProvider
+	}
+
+	return flags
+}
+
+// Close is an implementation to close MaxMind Anonymous IP database if it is open
+func (c *NetworkClassifier) Close() error {
+	if c.anonymousIP == nil {
+		return nil
+	}
+
+	return c.anonymousIP.Close()
+}
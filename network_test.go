@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNetworkClassifier(t *testing.T) {
+	type args struct {
+		ip  string
+		asn *int64
+	}
+	type test struct {
+		name string
+		args args
+		want NetworkFlags
+	}
+	hostingASN, residentialASN := int64(14061), int64(11427)
+	dir, err := ioutil.TempDir("", "network")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	torFile := filepath.Join(dir, "tor-exit-list.txt")
+	err = ioutil.WriteFile(torFile, []byte(`# exit list of the Tor Project
+ExitNode 0011BD2485AD45D984EC4159C88FC066E5E3300E
+Published 2018-01-01 00:00:00
+LastStatus 2018-01-01 01:00:00
+ExitAddress 162.247.74.201 2018-01-01 01:02:03
+2001:db8::7
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write the Tor exit list, error: %v", err)
+	}
+	hostingFile := filepath.Join(dir, "hosting.txt")
+	err = ioutil.WriteFile(hostingFile, []byte(`# a cloud
+3.0.0.0/9
+2600:1f00::/24
+52.95.110.1
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write the hosting list, error: %v", err)
+	}
+
+	hostingASNFile := filepath.Join(dir, "hosting-asn.txt")
+	err = ioutil.WriteFile(hostingASNFile, []byte(`# a cloud
+AS16509
+14061
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write the hosting ASN list, error: %v", err)
+	}
+
+	c, err := NewNetworkClassifier(&Config{TorExitFile: torFile, HostingFile: hostingFile, HostingASNFile: hostingASNFile})
+	if err != nil {
+		t.Fatalf("failed to instantiate, error: %v", err)
+	}
+	defer c.Close()
+
+	flags := func(tor, anonymizer, hosting bool) NetworkFlags {
+		return NetworkFlags{Tor: &tor, Anonymizer: &anonymizer, Hosting: &hosting}
+	}
+	tests := []test{
+		{
+			name: "Check Tor exit node of the exit list",
+			args: args{ip: "162.247.74.201"},
+			want: flags(true, true, false),
+		},
+		{
+			name: "Check Tor exit node of an ip address per line",
+			args: args{ip: "2001:db8::7"},
+			want: flags(true, true, false),
+		},
+		{
+			name: "Check hosting range",
+			args: args{ip: "3.1.2.3"},
+			want: flags(false, false, true),
+		},
+		{
+			name: "Check hosting ip address",
+			args: args{ip: "52.95.110.1"},
+			want: flags(false, false, true),
+		},
+		{
+			name: "Check hosting autonomous system",
+			args: args{ip: "159.203.1.1", asn: &hostingASN},
+			want: flags(false, false, true),
+		},
+		{
+			name: "Check residential ip address",
+			args: args{ip: "24.242.71.20", asn: &residentialASN},
+			want: flags(false, false, false),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.Classify(net.ParseIP(tt.args.ip), tt.args.asn)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %v, want: %v", fmt.Sprint(*got.Tor, *got.Anonymizer, *got.Hosting), fmt.Sprint(*tt.want.Tor, *tt.want.Anonymizer, *tt.want.Hosting))
+			}
+		})
+	}
+
+	// the current lists are kept when a file is broken
+	ioutil.WriteFile(hostingFile, []byte("3.0.0.0/33\n"), 0644)
+	if err := c.Reload(); err == nil {
+		t.Errorf("got nil error, want an error of the broken hosting list")
+	}
+	if got := c.Classify(net.ParseIP("3.1.2.3"), nil); !*got.Hosting {
+		t.Errorf("hosting list is not kept after the failed reload")
+	}
+}
+
+func TestNewNetworkClassifier(t *testing.T) {
+	c, err := NewNetworkClassifier(&Config{AnonymousIPDBPath: "missing.mmdb"})
+	if err != nil || c != nil {
+		t.Errorf("got: %v, error: %v, want: nil without Anonymous IP database", c, err)
+	}
+
+	dir, err := ioutil.TempDir("", "network")
+	if err != nil {
+		t.Fatalf("failed to create a directory, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tor.txt")
+	ioutil.WriteFile(path, []byte("not-an-ip\n"), 0644)
+	_, err = NewNetworkClassifier(&Config{TorExitFile: path})
+	want := fmt.Errorf("%s:1: invalid ip address not-an-ip", path)
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("error not the same, want: %v, got: %v", want, err)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+// kilometersPerMile is a ratio to convert a speed in km/h into mph
+const kilometersPerMile = 1.609344
+
+// PolicyStore is an implementation to hold the speed policies overridden per user and per user group.
+// The overrides are persisted to a JSON file when the path is given.
+type PolicyStore struct {
+	mu       sync.RWMutex
+	path     string
+	policies *supermandetector.SpeedPolicies
+}
+
+// ParseSpeedUnit is an implementation to parse the speed unit in the configuration
+func ParseSpeedUnit(s string) (supermandetector.SpeedUnit, error) {
+	switch strings.ToLower(s) {
+	case "mph":
+		return supermandetector.MPH, nil
+	case "kmh", "km/h", "kph":
+		return supermandetector.KMH, nil
+	default:
+		return 0, fmt.Errorf("unknown speed unit: %s", s)
+	}
+}
+
+// ValidateSpeedPolicy is an implementation to check the threshold and the unit of a speed policy
+func ValidateSpeedPolicy(policy *supermandetector.SpeedPolicy) error {
+	if policy == nil {
+		return fmt.Errorf("speed policy is missing")
+	}
+	if policy.Threshold <= 0 {
+		return fmt.Errorf("speed policy threshold must be positive: %v", policy.Threshold)
+	}
+	if policy.Unit != supermandetector.MPH && policy.Unit != supermandetector.KMH {
+		return fmt.Errorf("speed policy unit must be MPH or KMH")
+	}
+
+	return nil
+}
+
+// ThresholdInMph is an implementation to get the threshold of a speed policy in mph
+func ThresholdInMph(policy *supermandetector.SpeedPolicy) float64 {
+	if policy.Unit == supermandetector.KMH {
+		return policy.Threshold / kilometersPerMile
+	}
+
+	return policy.Threshold
+}
+
+// NewPolicyStore is an implementation to initialize a PolicyStore with the default policy and the overrides loaded from the path
+func NewPolicyStore(defaultPolicy *supermandetector.SpeedPolicy, path string) (*PolicyStore, error) {
+	err := ValidateSpeedPolicy(defaultPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := supermandetector.NewSpeedPolicies()
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			err = json.Unmarshal(b, policies)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	policies.DefaultPolicy = defaultPolicy
+
+	return &PolicyStore{path: path, policies: policies}, nil
+}
+
+// SpeedPolicies is an implementation to get a copy of all speed policies
+func (ps *PolicyStore) SpeedPolicies() *supermandetector.SpeedPolicies {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	policies := supermandetector.NewSpeedPolicies()
+	p := *ps.policies.DefaultPolicy
+	policies.DefaultPolicy = &p
+	for _, g := range ps.policies.Groups {
+		policies.Groups = append(policies.Groups, copySpeedPolicyGroup(g))
+	}
+	for username, up := range ps.policies.Users {
+		p := *up
+		policies.Users[username] = &p
+	}
+
+	return policies
+}
+
+// Policy is an implementation to resolve the speed policy of the user.
+// A policy of the user takes precedence, then the most permissive policy of the groups the user belongs to, and then the default policy.
+func (ps *PolicyStore) Policy(username string) *supermandetector.SpeedPolicy {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if p, ok := ps.policies.Users[username]; ok {
+		return p
+	}
+
+	var policy *supermandetector.SpeedPolicy
+	for _, g := range ps.policies.Groups {
+		for _, u := range g.Usernames {
+			if u == username && (policy == nil || ThresholdInMph(g.Policy) > ThresholdInMph(policy)) {
+				policy = g.Policy
+			}
+		}
+	}
+	if policy != nil {
+		return policy
+	}
+
+	return ps.policies.DefaultPolicy
+}
+
+// Threshold is an implementation to get the speed threshold of the user in mph
+func (ps *PolicyStore) Threshold(username string) float64 {
+	return ThresholdInMph(ps.Policy(username))
+}
+
+// PutUserPolicy is an implementation to override the speed policy of the user
+func (ps *PolicyStore) PutUserPolicy(username string, policy *supermandetector.SpeedPolicy) error {
+	err := ValidateSpeedPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	p := *policy
+	ps.policies.Users[username] = &p
+
+	return ps.save()
+}
+
+// DeleteUserPolicy is an implementation to delete the override of the user, it returns false if there is none
+func (ps *PolicyStore) DeleteUserPolicy(username string) (bool, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, ok := ps.policies.Users[username]; !ok {
+		return false, nil
+	}
+	delete(ps.policies.Users, username)
+
+	return true, ps.save()
+}
+
+// EraseUser is an implementation to delete the override of the user and to remove the user from all groups.
+// It returns whether an override is deleted and the names of the groups the user is removed from.
+func (ps *PolicyStore) EraseUser(username string) (bool, []string, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	_, policy := ps.policies.Users[username]
+	delete(ps.policies.Users, username)
+
+	groups := []string{}
+	for _, g := range ps.policies.Groups {
+		usernames := g.Usernames[:0]
+		for _, u := range g.Usernames {
+			if u != username {
+				usernames = append(usernames, u)
+			}
+		}
+		if len(usernames) != len(g.Usernames) {
+			groups = append(groups, g.Name)
+		}
+		g.Usernames = usernames
+	}
+	if !policy && len(groups) == 0 {
+		return false, groups, nil
+	}
+
+	return policy, groups, ps.save()
+}
+
+// UserPolicy is an implementation to get a copy of the override of the user and the names of the groups the user belongs to
+func (ps *PolicyStore) UserPolicy(username string) (*supermandetector.SpeedPolicy, []string) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	var policy *supermandetector.SpeedPolicy
+	if up, ok := ps.policies.Users[username]; ok {
+		p := *up
+		policy = &p
+	}
+	groups := []string{}
+	for _, g := range ps.policies.Groups {
+		for _, u := range g.Usernames {
+			if u == username {
+				groups = append(groups, g.Name)
+				break
+			}
+		}
+	}
+
+	return policy, groups
+}
+
+// PutGroup is an implementation to create or replace a user group with its speed policy
+func (ps *PolicyStore) PutGroup(group *supermandetector.SpeedPolicyGroup) error {
+	if group.Name == "" {
+		return fmt.Errorf("speed policy group name is missing")
+	}
+	err := ValidateSpeedPolicy(group.Policy)
+	if err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	g := copySpeedPolicyGroup(group)
+	for i, old := range ps.policies.Groups {
+		if old.Name == g.Name {
+			ps.policies.Groups[i] = g
+			return ps.save()
+		}
+	}
+	ps.policies.Groups = append(ps.policies.Groups, g)
+	sort.Slice(ps.policies.Groups, func(i, j int) bool { return ps.policies.Groups[i].Name < ps.policies.Groups[j].Name })
+
+	return ps.save()
+}
+
+// DeleteGroup is an implementation to delete a user group, it returns false if there is none
+func (ps *PolicyStore) DeleteGroup(name string) (bool, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for i, g := range ps.policies.Groups {
+		if g.Name == name {
+			ps.policies.Groups = append(ps.policies.Groups[:i], ps.policies.Groups[i+1:]...)
+			return true, ps.save()
+		}
+	}
+
+	return false, nil
+}
+
+// save writes the overrides to the file atomically, it must be called with the lock held
+func (ps *PolicyStore) save() error {
+	if ps.path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(ps.policies, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ps.path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, ps.path)
+}
+
+func copySpeedPolicyGroup(group *supermandetector.SpeedPolicyGroup) *supermandetector.SpeedPolicyGroup {
+	p := *group.Policy
+	usernames := make([]string, len(group.Usernames))
+	copy(usernames, group.Usernames)
+
+	return supermandetector.NewSpeedPolicyGroup(&supermandetector.SpeedPolicyGroup{
+		Name:      group.Name,
+		Policy:    &p,
+		Usernames: usernames,
+	})
+}
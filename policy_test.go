@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"testing"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+func TestPolicyStoreThreshold(t *testing.T) {
+	type args struct {
+		defaultPolicy *supermandetector.SpeedPolicy
+		users         map[string]*supermandetector.SpeedPolicy
+		groups        []*supermandetector.SpeedPolicyGroup
+		username      string
+	}
+	type test struct {
+		name string
+		args args
+		want float64
+	}
+	defaultPolicy := &supermandetector.SpeedPolicy{Threshold: 500, Unit: supermandetector.MPH}
+	groups := []*supermandetector.SpeedPolicyGroup{
+		&supermandetector.SpeedPolicyGroup{
+			Name:      "airline-crew",
+			Policy:    &supermandetector.SpeedPolicy{Threshold: 700, Unit: supermandetector.MPH},
+			Usernames: []string{"alice", "carol"},
+		},
+		&supermandetector.SpeedPolicyGroup{
+			Name:      "vpn-engineers",
+			Policy:    &supermandetector.SpeedPolicy{Threshold: 1609.344, Unit: supermandetector.KMH},
+			Usernames: []string{"carol"},
+		},
+	}
+	tests := []test{
+		{
+			name: "Check default policy",
+			args: args{
+				defaultPolicy: defaultPolicy,
+				groups:        groups,
+				username:      "bob",
+			},
+			want: 500,
+		},
+		{
+			name: "Check default policy in km/h",
+			args: args{
+				defaultPolicy: &supermandetector.SpeedPolicy{Threshold: 804.672, Unit: supermandetector.KMH},
+				username:      "bob",
+			},
+			want: 500,
+		},
+		{
+			name: "Check group policy",
+			args: args{
+				defaultPolicy: defaultPolicy,
+				groups:        groups,
+				username:      "alice",
+			},
+			want: 700,
+		},
+		{
+			name: "Check most permissive group policy",
+			args: args{
+				defaultPolicy: defaultPolicy,
+				groups:        groups,
+				username:      "carol",
+			},
+			want: 1000,
+		},
+		{
+			name: "Check user policy over group policy",
+			args: args{
+				defaultPolicy: defaultPolicy,
+				users: map[string]*supermandetector.SpeedPolicy{
+					"carol": &supermandetector.SpeedPolicy{Threshold: 300, Unit: supermandetector.MPH},
+				},
+				groups:   groups,
+				username: "carol",
+			},
+			want: 300,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := NewPolicyStore(tt.args.defaultPolicy, "")
+			if err != nil {
+				t.Errorf("failed to instantiate, error: %v", err)
+				return
+			}
+			for _, g := range tt.args.groups {
+				ps.PutGroup(g)
+			}
+			for u, p := range tt.args.users {
+				ps.PutUserPolicy(u, p)
+			}
+
+			got := ps.Threshold(tt.args.username)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyStorePersistence(t *testing.T) {
+	path := "./policy_test.json"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	defaultPolicy := &supermandetector.SpeedPolicy{Threshold: 500, Unit: supermandetector.MPH}
+	ps, err := NewPolicyStore(defaultPolicy, path)
+	if err != nil {
+		t.Errorf("failed to instantiate, error: %v", err)
+		return
+	}
+	err = ps.PutUserPolicy("bob", &supermandetector.SpeedPolicy{Threshold: 600, Unit: supermandetector.MPH})
+	if err != nil {
+		t.Errorf("failed to put user policy, error: %v", err)
+		return
+	}
+	err = ps.PutUserPolicy("alice", &supermandetector.SpeedPolicy{Threshold: 600})
+	if err == nil || err.Error() != fmt.Errorf("speed policy unit must be MPH or KMH").Error() {
+		t.Errorf("got: %v, want: speed policy unit must be MPH or KMH", err)
+	}
+
+	ps, err = NewPolicyStore(defaultPolicy, path)
+	if err != nil {
+		t.Errorf("failed to reload, error: %v", err)
+		return
+	}
+	if got := ps.Threshold("bob"); got != 600 {
+		t.Errorf("got: %v, want: %v", got, 600)
+	}
+
+	ok, err := ps.DeleteUserPolicy("bob")
+	if !ok || err != nil {
+		t.Errorf("failed to delete user policy, ok: %v, error: %v", ok, err)
+	}
+	if got := ps.Threshold("bob"); got != 500 {
+		t.Errorf("got: %v, want: %v", got, 500)
+	}
+}
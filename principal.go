@@ -0,0 +1,38 @@
+package main
+
+// SimplePrincipal is an implementation of rdl.Principal for the identities authenticated by this service
+type SimplePrincipal struct {
+	Domain      string
+	Name        string
+	Credentials string
+	HTTPHeader  string
+}
+
+// GetDomain is an implementation to get the domain of the principal
+func (p *SimplePrincipal) GetDomain() string {
+	return p.Domain
+}
+
+// GetName is an implementation to get the name of the principal
+func (p *SimplePrincipal) GetName() string {
+	return p.Name
+}
+
+// GetYRN is an implementation to get the full name of the principal in the form of domain.name
+func (p *SimplePrincipal) GetYRN() string {
+	if p.Domain == "" {
+		return p.Name
+	}
+
+	return p.Domain + "." + p.Name
+}
+
+// GetCredentials is an implementation to get the credentials the principal is authenticated with
+func (p *SimplePrincipal) GetCredentials() string {
+	return p.Credentials
+}
+
+// GetHTTPHeaderName is an implementation to get the header the credentials are stored in
+func (p *SimplePrincipal) GetHTTPHeaderName() string {
+	return p.HTTPHeader
+}
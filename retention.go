@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+// defaultTenant is the key of the records of no tenant with an override in the metrics of the purge
+const defaultTenant = "*"
+
+// RetentionPolicy is how long the records are kept, overridden per tenant of the principals which registered them.
+// A tenant is the domain of the principals, so the override of a tenant applies to the principals in its domain
+// unless a more specific tenant overrides it again.
+type RetentionPolicy struct {
+	// Default is the retention of the records of no tenant with an override, 0 keeps them forever
+	Default time.Duration
+	// Tenants is the retention per tenant, 0 keeps the records of the tenant forever
+	Tenants map[string]time.Duration
+}
+
+// retentionRule is a retention to purge the records of the tenant, except those of the excluded tenants
+type retentionRule struct {
+	tenant    string
+	excluded  []string
+	retention time.Duration
+}
+
+// ParseRetention is an implementation to parse a retention in days such as 90d, or in a duration such as 2160h
+func ParseRetention(s string) (time.Duration, error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid retention: %s", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid retention: %s", s)
+	}
+
+	return d, nil
+}
+
+// FormatRetention is an implementation to format a retention in days when it is a whole number of days
+func FormatRetention(d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+	if d%(24*time.Hour) == 0 {
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	}
+
+	return d.String()
+}
+
+// NewRetentionPolicy is an implementation to initialize a RetentionPolicy from the retention of the configuration
+func NewRetentionPolicy(config *Config) (*RetentionPolicy, error) {
+	d, err := ParseRetention(config.Retention)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &RetentionPolicy{Default: d, Tenants: map[string]time.Duration{}}
+	for tenant, s := range config.TenantRetention {
+		if tenant == "" {
+			return nil, fmt.Errorf("tenant of retention is missing")
+		}
+		d, err := ParseRetention(s)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %v", tenant, err)
+		}
+		rp.Tenants[tenant] = d
+	}
+
+	return rp, nil
+}
+
+// Enabled is an implementation to tell whether any records expire under the policy
+func (rp *RetentionPolicy) Enabled() bool {
+	if rp.Default > 0 {
+		return true
+	}
+	for _, d := range rp.Tenants {
+		if d > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rules resolves the policy into the rules to purge, each tenant excluding the more specific tenants overriding it
+func (rp *RetentionPolicy) rules() []retentionRule {
+	tenants := make([]string, 0, len(rp.Tenants))
+	for tenant := range rp.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	rules := []retentionRule{}
+	if rp.Default > 0 {
+		rules = append(rules, retentionRule{tenant: "", excluded: tenants, retention: rp.Default})
+	}
+	for _, tenant := range tenants {
+		if rp.Tenants[tenant] == 0 {
+			continue
+		}
+		rule := retentionRule{tenant: tenant, excluded: []string{}, retention: rp.Tenants[tenant]}
+		for _, t := range tenants {
+			if t != tenant && IsTenantPrincipal(tenant, t) {
+				rule.excluded = append(rule.excluded, t)
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// Purger is an implementation to delete the expired records in background, in batches not to block the registrations for long
+type Purger struct {
+	store     AccessStore
+	policy    *RetentionPolicy
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+	stop      chan struct{}
+	done      chan struct{}
+
+	mu             sync.Mutex
+	started        bool
+	purged         int64
+	purgedByTenant map[string]int64
+	runs           int64
+	lastRun        time.Time
+	lastErr        error
+}
+
+// NewPurger is an implementation to initialize a Purger of the store under the retention policy
+func NewPurger(store AccessStore, policy *RetentionPolicy, interval time.Duration, batchSize int) (*Purger, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid retention interval: %v", interval)
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("invalid retention batch size: %d", batchSize)
+	}
+
+	return &Purger{
+		store:          store,
+		policy:         policy,
+		interval:       interval,
+		batchSize:      batchSize,
+		now:            time.Now,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+		purgedByTenant: map[string]int64{},
+	}, nil
+}
+
+// Start is an implementation to purge the expired records now and then every interval until it is stopped
+func (p *Purger) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return
+	}
+	p.started = true
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.Purge()
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop is an implementation to stop the purge after the batch in progress, and to wait for it.
+// A stopped Purger is not started again.
+func (p *Purger) Stop() {
+	p.mu.Lock()
+	started := p.started
+	p.mu.Unlock()
+
+	select {
+	case <-p.stop:
+		return
+	default:
+		close(p.stop)
+	}
+	if started {
+		<-p.done
+	}
+}
+
+// Purge is an implementation to delete all expired records batch by batch, and returns the number of the deleted records
+func (p *Purger) Purge() (int, error) {
+	now := p.now()
+	total := 0
+	var err error
+	for _, rule := range p.policy.rules() {
+		tenant := rule.tenant
+		if tenant == "" {
+			tenant = defaultTenant
+		}
+		before := now.Add(-rule.retention).UnixNano() / int64(time.Millisecond)
+
+		for {
+			var n int
+			n, err = p.store.PurgeIpAccessRecords(rule.tenant, rule.excluded, before, p.batchSize)
+			total += n
+			p.mu.Lock()
+			p.purged += int64(n)
+			p.purgedByTenant[tenant] += int64(n)
+			p.mu.Unlock()
+			if err != nil || n < p.batchSize || p.stopped() {
+				break
+			}
+		}
+		if err != nil {
+			log.Printf("Failed to purge IpAccessRecords of tenant %s, Error:%v\n", tenant, err)
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("Purged %d expired IpAccessRecords\n", total)
+	}
+
+	p.mu.Lock()
+	p.runs++
+	p.lastRun = now
+	p.lastErr = err
+	p.mu.Unlock()
+
+	return total, err
+}
+
+// stopped reports whether the purge is requested to stop
+func (p *Purger) stopped() bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// RetentionStatus is an implementation to get the retention policy and the metrics of the purge
+func (p *Purger) RetentionStatus() *supermandetector.RetentionStatus {
+	status := NewRetentionStatus(p.policy)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status.Purged = p.purged
+	for tenant, n := range p.purgedByTenant {
+		status.PurgedByTenant[tenant] = n
+	}
+	status.Runs = p.runs
+	if p.runs > 0 {
+		status.LastRun = &rdl.Timestamp{Time: p.lastRun.UTC()}
+	}
+	if p.lastErr != nil {
+		status.LastError = p.lastErr.Error()
+	}
+
+	return status
+}
+
+// NewRetentionStatus is an implementation to initialize a RetentionStatus of the retention policy without metrics
+func NewRetentionStatus(policy *RetentionPolicy) *supermandetector.RetentionStatus {
+	status := supermandetector.NewRetentionStatus()
+	if policy.Default > 0 {
+		status.Retention = FormatRetention(policy.Default)
+	}
+	for tenant, d := range policy.Tenants {
+		status.TenantRetention[tenant] = FormatRetention(d)
+	}
+
+	return status
+}
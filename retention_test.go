@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+func TestParseRetention(t *testing.T) {
+	type test struct {
+		name    string
+		s       string
+		want    time.Duration
+		wantErr error
+	}
+	tests := []test{
+		{
+			name: "Check retention in days",
+			s:    "90d",
+			want: 90 * 24 * time.Hour,
+		},
+		{
+			name: "Check retention in a duration",
+			s:    "36h",
+			want: 36 * time.Hour,
+		},
+		{
+			name: "Check retention kept forever",
+			s:    "0",
+			want: 0,
+		},
+		{
+			name:    "Check invalid retention",
+			s:       "-1d",
+			wantErr: fmt.Errorf("invalid retention: -1d"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetention(tt.s)
+			if !reflect.DeepEqual(tt.wantErr, err) {
+				t.Errorf("error not the same, want: %v, got: %v", tt.wantErr, err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurger(t *testing.T) {
+	type args struct {
+		driver string
+		dsn    string
+	}
+	type test struct {
+		name       string
+		args       args
+		beforeFunc func()
+		afterFunc  func()
+	}
+	now := time.Unix(1514764800, 0)
+	day := int64(24 * time.Hour / time.Millisecond)
+	records := []*supermandetector.IpAccessRecord{
+		// expired by the default retention of 30 days
+		&supermandetector.IpAccessRecord{Username: "bob", Timestamp_ms: now.Unix()*1000 - 31*day, Event_uuid: "1", Ip_address: "24.242.71.20"},
+		&supermandetector.IpAccessRecord{Username: "bob", Timestamp_ms: now.Unix()*1000 - 31*day, Event_uuid: "2", Ip_address: "24.242.71.20", Principal: "beta.collector"},
+		&supermandetector.IpAccessRecord{Username: "bob", Timestamp_ms: now.Unix()*1000 - 2*day, Event_uuid: "3", Ip_address: "24.242.71.20"},
+		// expired by the retention of 1 day of tenant acme
+		&supermandetector.IpAccessRecord{Username: "alice", Timestamp_ms: now.Unix()*1000 - 2*day, Event_uuid: "4", Ip_address: "24.242.71.20", Principal: "acme.collector"},
+		&supermandetector.IpAccessRecord{Username: "alice", Timestamp_ms: now.Unix() * 1000, Event_uuid: "5", Ip_address: "24.242.71.20", Principal: "acme.collector"},
+		// kept forever by tenant acme.audit
+		&supermandetector.IpAccessRecord{Username: "alice", Timestamp_ms: now.Unix()*1000 - 400*day, Event_uuid: "6", Ip_address: "24.242.71.20", Principal: "acme.audit.collector"},
+		// tenant acme_ is not in the domain of acme
+		&supermandetector.IpAccessRecord{Username: "alice", Timestamp_ms: now.Unix()*1000 - 2*day, Event_uuid: "7", Ip_address: "24.242.71.20", Principal: "acme_.collector"},
+	}
+	tests := []test{
+		{
+			name: "Check memory",
+			args: args{
+				driver: "memory",
+			},
+		},
+		{
+			name: "Check sqlite3",
+			args: args{
+				driver: "sqlite3",
+				dsn:    "./ipaccess_purge_test.db",
+			},
+			beforeFunc: func() {
+				os.Remove("./ipaccess_purge_test.db")
+			},
+			afterFunc: func() {
+				os.Remove("./ipaccess_purge_test.db")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.beforeFunc != nil {
+				tt.beforeFunc()
+			}
+			if tt.afterFunc != nil {
+				defer tt.afterFunc()
+			}
+
+			store, err := NewAccessStore(tt.args.driver, tt.args.dsn)
+			if err != nil {
+				t.Errorf("failed to instantiate, error: %v", err)
+				return
+			}
+			defer store.Close()
+			err = store.RegisterIpAccessRecords(records)
+			if err != nil {
+				t.Errorf("failed to register, error: %v", err)
+				return
+			}
+
+			policy, err := NewRetentionPolicy(&Config{
+				Retention:       "30d",
+				TenantRetention: map[string]string{"acme": "1d", "acme.audit": "0"},
+			})
+			if err != nil {
+				t.Errorf("failed to parse retention, error: %v", err)
+				return
+			}
+			purger, err := NewPurger(store, policy, time.Hour, 1)
+			if err != nil {
+				t.Errorf("failed to instantiate purger, error: %v", err)
+				return
+			}
+			purger.now = func() time.Time { return now }
+
+			n, err := purger.Purge()
+			if err != nil {
+				t.Errorf("failed to purge, error: %v", err)
+				return
+			}
+			if n != 3 {
+				t.Errorf("purged got: %d, want: 3", n)
+			}
+
+			kept := []string{}
+			for _, username := range []string{"alice", "bob"} {
+				list, _ := store.ListIpAccessRecords(username)
+				for _, r := range list {
+					kept = append(kept, r.Event_uuid)
+				}
+			}
+			sort.Strings(kept)
+			if !reflect.DeepEqual(kept, []string{"3", "5", "6", "7"}) {
+				t.Errorf("kept got: %v, want: %v", kept, []string{"3", "5", "6", "7"})
+			}
+
+			status := purger.RetentionStatus()
+			want := &supermandetector.RetentionStatus{
+				Retention:       "30d",
+				TenantRetention: map[string]string{"acme": "1d", "acme.audit": "0"},
+				Purged:          3,
+				PurgedByTenant:  map[string]int64{"*": 2, "acme": 1},
+				Runs:            1,
+				LastRun:         status.LastRun,
+			}
+			if !reflect.DeepEqual(status, want) {
+				t.Errorf("status got: %+v, want: %+v", status, want)
+			}
+		})
+	}
+}
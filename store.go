@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+var (
+	// ErrAccessStoreClosed is returned when an operation is requested to a closed AccessStore
+	ErrAccessStoreClosed = errors.New("access store is closed")
+	// ErrDuplicateEvent is returned when a record with the same event uuid is already registered
+	ErrDuplicateEvent = errors.New("event is already registered")
+)
+
+// AccessStore is an interface to persist ip access records and to look them up per user
+type AccessStore interface {
+	// RegisterIpAccessRecord registers an ip access record
+	RegisterIpAccessRecord(ipRecord *supermandetector.IpAccessRecord) error
+	// RegisterIpAccessRecords registers the ip access records in one transaction, none of them if any fails
+	RegisterIpAccessRecords(ipRecords []*supermandetector.IpAccessRecord) error
+	// GetIpAccessRecord returns the record identified by the event uuid, or nil if there is none
+	GetIpAccessRecord(eventUuid string) (*supermandetector.IpAccessRecord, error)
+	// GetPrecedingIpAccessRecord returns the nearest record of the user before the timestamp other than the event itself, or nil if there is none.
+	// Records with the same timestamp are ordered by the event uuid.
+	GetPrecedingIpAccessRecord(username string, timestampMs int64, eventUuid string) (*supermandetector.IpAccessRecord, error)
+	// GetSubsequentIpAccessRecord returns the nearest record of the user after the timestamp other than the event itself, or nil if there is none.
+	// Records with the same timestamp are ordered by the event uuid.
+	GetSubsequentIpAccessRecord(username string, timestampMs int64, eventUuid string) (*supermandetector.IpAccessRecord, error)
+	// DeleteIpAccessRecord deletes the record identified by the event uuid
+	DeleteIpAccessRecord(eventUuid string) error
+	// DeleteIpAccessRecords deletes all records of the user and returns the number of the deleted records
+	DeleteIpAccessRecords(username string) (int, error)
+	// PurgeIpAccessRecords deletes at most limit records before the timestamp registered by the principals of the tenant,
+	// except those of the excluded tenants, and returns the number of the deleted records. The empty tenant matches all principals.
+	PurgeIpAccessRecords(tenant string, excluded []string, timestampMs int64, limit int) (int, error)
+	// ListIpAccessRecords returns all records of the user ordered by timestamp and event uuid
+	ListIpAccessRecords(username string) ([]*supermandetector.IpAccessRecord, error)
+	// ListIpAccessRecordsBetween returns at most limit records of the user after the timestamp and event uuid and before the end timestamp,
+	// ordered by timestamp and event uuid
+	ListIpAccessRecordsBetween(username string, timestampMs int64, eventUuid string, endMs int64, limit int) ([]*supermandetector.IpAccessRecord, error)
+	// Close releases the resources held by the store
+	Close() error
+}
+
+// IsTenantPrincipal reports whether the principal belongs to the tenant, that is the principal is the tenant itself or a name in the domain of the tenant
+func IsTenantPrincipal(tenant string, principal string) bool {
+	return tenant == "" || principal == tenant || strings.HasPrefix(principal, tenant+".")
+}
+
+// NewAccessStore is an implementation to open an AccessStore for the storage driver
+func NewAccessStore(driver string, dsn string) (AccessStore, error) {
+	switch driver {
+	case "sqlite3":
+		return NewSQLiteAccessStore(dsn)
+	case "postgres":
+		return NewPostgresAccessStore(dsn)
+	case "memory":
+		return NewMemoryAccessStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", driver)
+	}
+}
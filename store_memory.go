@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+// memoryAccessStore is an AccessStore which keeps records in memory only
+type memoryAccessStore struct {
+	mu      sync.RWMutex
+	records map[string]*supermandetector.IpAccessRecord
+	users   map[string][]*supermandetector.IpAccessRecord
+	closed  bool
+}
+
+// NewMemoryAccessStore is an implementation to initialize an in-memory store for ip access record
+func NewMemoryAccessStore() AccessStore {
+	return &memoryAccessStore{
+		records: map[string]*supermandetector.IpAccessRecord{},
+		users:   map[string][]*supermandetector.IpAccessRecord{},
+	}
+}
+
+// RegisterIpAccessRecord is an implementation to register ip access to memory as a record
+func (store *memoryAccessStore) RegisterIpAccessRecord(ipRecord *supermandetector.IpAccessRecord) error {
+	return store.RegisterIpAccessRecords([]*supermandetector.IpAccessRecord{ipRecord})
+}
+
+// RegisterIpAccessRecords is an implementation to register ip accesses to memory as records, none of them if any event uuid is already registered
+func (store *memoryAccessStore) RegisterIpAccessRecords(ipRecords []*supermandetector.IpAccessRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.closed {
+		return ErrAccessStoreClosed
+	}
+	uuids := map[string]bool{}
+	for _, ipRecord := range ipRecords {
+		if _, ok := store.records[ipRecord.Event_uuid]; ok || uuids[ipRecord.Event_uuid] {
+			return fmt.Errorf("event_uuid %s: %w", ipRecord.Event_uuid, ErrDuplicateEvent)
+		}
+		uuids[ipRecord.Event_uuid] = true
+	}
+
+	for _, ipRecord := range ipRecords {
+		r := *ipRecord
+		records := store.users[r.Username]
+		i := sort.Search(len(records), func(i int) bool { return after(records[i], r.Timestamp_ms, r.Event_uuid) })
+		records = append(records, nil)
+		copy(records[i+1:], records[i:])
+		records[i] = &r
+		store.users[r.Username] = records
+		store.records[r.Event_uuid] = &r
+	}
+
+	return nil
+}
+
+// GetIpAccessRecord is an implementation to get a record by the event uuid
+func (store *memoryAccessStore) GetIpAccessRecord(eventUuid string) (*supermandetector.IpAccessRecord, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if store.closed {
+		return nil, ErrAccessStoreClosed
+	}
+	ipRecord, ok := store.records[eventUuid]
+	if !ok {
+		return nil, nil
+	}
+
+	r := *ipRecord
+	return &r, nil
+}
+
+// GetPrecedingIpAccessRecord is an implementation to get a nearest preceding record of the user
+func (store *memoryAccessStore) GetPrecedingIpAccessRecord(username string, timestampMs int64, eventUuid string) (*supermandetector.IpAccessRecord, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if store.closed {
+		return nil, ErrAccessStoreClosed
+	}
+
+	records := store.users[username]
+	i := sort.Search(len(records), func(i int) bool { return !before(records[i], timestampMs, eventUuid) })
+	if i > 0 && records[i-1].Event_uuid == eventUuid {
+		i--
+	}
+	if i == 0 {
+		return nil, nil
+	}
+	r := *records[i-1]
+
+	return &r, nil
+}
+
+// GetSubsequentIpAccessRecord is an implementation to get a nearest subsequent record of the user
+func (store *memoryAccessStore) GetSubsequentIpAccessRecord(username string, timestampMs int64, eventUuid string) (*supermandetector.IpAccessRecord, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if store.closed {
+		return nil, ErrAccessStoreClosed
+	}
+
+	records := store.users[username]
+	i := sort.Search(len(records), func(i int) bool { return after(records[i], timestampMs, eventUuid) })
+	if i < len(records) && records[i].Event_uuid == eventUuid {
+		i++
+	}
+	if i == len(records) {
+		return nil, nil
+	}
+	r := *records[i]
+
+	return &r, nil
+}
+
+// before reports whether the record is ordered before the timestamp and event uuid
+func before(ipRecord *supermandetector.IpAccessRecord, timestampMs int64, eventUuid string) bool {
+	if ipRecord.Timestamp_ms != timestampMs {
+		return ipRecord.Timestamp_ms < timestampMs
+	}
+	return ipRecord.Event_uuid < eventUuid
+}
+
+// after reports whether the record is ordered after the timestamp and event uuid
+func after(ipRecord *supermandetector.IpAccessRecord, timestampMs int64, eventUuid string) bool {
+	if ipRecord.Timestamp_ms != timestampMs {
+		return ipRecord.Timestamp_ms > timestampMs
+	}
+	return ipRecord.Event_uuid > eventUuid
+}
+
+// DeleteIpAccessRecord is an implementation to delete a record by the event uuid
+func (store *memoryAccessStore) DeleteIpAccessRecord(eventUuid string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.closed {
+		return ErrAccessStoreClosed
+	}
+
+	ipRecord, ok := store.records[eventUuid]
+	if !ok {
+		return nil
+	}
+	delete(store.records, eventUuid)
+
+	records := store.users[ipRecord.Username]
+	for i, r := range records {
+		if r == ipRecord {
+			records = append(records[:i], records[i+1:]...)
+			break
+		}
+	}
+	if len(records) == 0 {
+		delete(store.users, ipRecord.Username)
+	} else {
+		store.users[ipRecord.Username] = records
+	}
+
+	return nil
+}
+
+// DeleteIpAccessRecords is an implementation to delete all records of the user
+func (store *memoryAccessStore) DeleteIpAccessRecords(username string) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.closed {
+		return 0, ErrAccessStoreClosed
+	}
+
+	records := store.users[username]
+	for _, r := range records {
+		delete(store.records, r.Event_uuid)
+	}
+	delete(store.users, username)
+
+	return len(records), nil
+}
+
+// PurgeIpAccessRecords is an implementation to delete the expired records of the tenant in a batch
+func (store *memoryAccessStore) PurgeIpAccessRecords(tenant string, excluded []string, timestampMs int64, limit int) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.closed {
+		return 0, ErrAccessStoreClosed
+	}
+
+	n := 0
+	for username, records := range store.users {
+		kept := records[:0]
+		for _, r := range records {
+			if n < limit && r.Timestamp_ms < timestampMs && isPurged(tenant, excluded, r.Principal) {
+				delete(store.records, r.Event_uuid)
+				n++
+				continue
+			}
+			kept = append(kept, r)
+		}
+		if len(kept) == 0 {
+			delete(store.users, username)
+		} else {
+			store.users[username] = kept
+		}
+	}
+
+	return n, nil
+}
+
+// isPurged reports whether the records of the principal are purged by the retention of the tenant
+func isPurged(tenant string, excluded []string, principal string) bool {
+	if !IsTenantPrincipal(tenant, principal) {
+		return false
+	}
+	for _, e := range excluded {
+		if IsTenantPrincipal(e, principal) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ListIpAccessRecords is an implementation to list all records of the user
+func (store *memoryAccessStore) ListIpAccessRecords(username string) ([]*supermandetector.IpAccessRecord, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if store.closed {
+		return nil, ErrAccessStoreClosed
+	}
+
+	ipRecords := make([]*supermandetector.IpAccessRecord, 0, len(store.users[username]))
+	for _, r := range store.users[username] {
+		c := *r
+		ipRecords = append(ipRecords, &c)
+	}
+
+	return ipRecords, nil
+}
+
+// ListIpAccessRecordsBetween is an implementation to list the records of the user in a time window page by page
+func (store *memoryAccessStore) ListIpAccessRecordsBetween(username string, timestampMs int64, eventUuid string, endMs int64, limit int) ([]*supermandetector.IpAccessRecord, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if store.closed {
+		return nil, ErrAccessStoreClosed
+	}
+
+	records := store.users[username]
+	ipRecords := []*supermandetector.IpAccessRecord{}
+	for i := sort.Search(len(records), func(i int) bool { return after(records[i], timestampMs, eventUuid) }); i < len(records) && len(ipRecords) < limit; i++ {
+		if records[i].Timestamp_ms >= endMs {
+			break
+		}
+		c := *records[i]
+		ipRecords = append(ipRecords, &c)
+	}
+
+	return ipRecords, nil
+}
+
+// Close is an implementation to discard all records
+func (store *memoryAccessStore) Close() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.closed = true
+	store.records = nil
+	store.users = nil
+
+	return nil
+}
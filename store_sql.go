@@ -0,0 +1,256 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqlAccessStore is an AccessStore backed by a database/sql driver
+type sqlAccessStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewSQLiteAccessStore is an implementation to open sqlite database at the path for ip access record
+func NewSQLiteAccessStore(path string) (AccessStore, error) {
+	return newSQLAccessStore("sqlite3", path)
+}
+
+// NewPostgresAccessStore is an implementation to open postgresql database for ip access record
+func NewPostgresAccessStore(dsn string) (AccessStore, error) {
+	return newSQLAccessStore("postgres", dsn)
+}
+
+func newSQLAccessStore(driver string, dsn string) (*sqlAccessStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite3" {
+		// sqlite takes one writer at once, and every connection to :memory: opens a database of its own,
+		// so the accesses are serialized on a single connection instead of failing with database is locked
+		db.SetMaxOpenConns(1)
+	}
+
+	err = Migrate(db, driver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	version, err := SchemaVersion(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Printf("Opened %s access store at schema version %d\n", driver, version)
+
+	return &sqlAccessStore{db: db, driver: driver}, nil
+}
+
+// bind rewrites the ? placeholders of a query into the form of the driver
+func (store *sqlAccessStore) bind(query string) string {
+	return bindQuery(store.driver, query)
+}
+
+// bindQuery rewrites the ? placeholders of a query into the $n form when the driver is postgres
+func bindQuery(driver string, query string) string {
+	if driver != "postgres" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// RegisterIpAccessRecord is an implementation to register ip access to database as a record
+func (store *sqlAccessStore) RegisterIpAccessRecord(ipRecord *supermandetector.IpAccessRecord) error {
+	return store.RegisterIpAccessRecords([]*supermandetector.IpAccessRecord{ipRecord})
+}
+
+// ipAccessColumns are the columns of a record in ipaccess, in the order of ipAccessValues and ipAccessFields
+const ipAccessColumns = "username, timestamp_ms, event_uuid, ip_address, lat, lon, radius, principal, provider, label, is_tor, is_anonymizer, is_hosting, asn, as_organization, connection_type, country_code, subdivision, city, time_zone"
+
+// ipAccessPlaceholders are the placeholders of ipAccessColumns in an insert statement
+const ipAccessPlaceholders = "?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?"
+
+// ipAccessValues lists the values of the record to insert in the order of ipAccessColumns
+func ipAccessValues(ipRecord *supermandetector.IpAccessRecord) []interface{} {
+	return []interface{}{ipRecord.Username, ipRecord.Timestamp_ms, ipRecord.Event_uuid, ipRecord.Ip_address, ipRecord.Lat, ipRecord.Lon, ipRecord.Radius, ipRecord.Principal, ipRecord.Provider, ipRecord.Label, ipRecord.IsTor, ipRecord.IsAnonymizer, ipRecord.IsHosting, ipRecord.Asn, ipRecord.AsOrganization, ipRecord.ConnectionType, ipRecord.CountryCode, ipRecord.Subdivision, ipRecord.City, ipRecord.TimeZone}
+}
+
+// ipAccessFields lists the fields of the record to scan in the order of ipAccessColumns
+func ipAccessFields(ipRecord *supermandetector.IpAccessRecord) []interface{} {
+	return []interface{}{&ipRecord.Username, &ipRecord.Timestamp_ms, &ipRecord.Event_uuid, &ipRecord.Ip_address, &ipRecord.Lat, &ipRecord.Lon, &ipRecord.Radius, &ipRecord.Principal, &ipRecord.Provider, &ipRecord.Label, &ipRecord.IsTor, &ipRecord.IsAnonymizer, &ipRecord.IsHosting, &ipRecord.Asn, &ipRecord.AsOrganization, &ipRecord.ConnectionType, &ipRecord.CountryCode, &ipRecord.Subdivision, &ipRecord.City, &ipRecord.TimeZone}
+}
+
+// RegisterIpAccessRecords is an implementation to register ip accesses to database as records in one transaction
+func (store *sqlAccessStore) RegisterIpAccessRecords(ipRecords []*supermandetector.IpAccessRecord) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(store.bind("insert into ipaccess(" + ipAccessColumns + ") values(" + ipAccessPlaceholders + ")"))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, ipRecord := range ipRecords {
+		_, err = stmt.Exec(ipAccessValues(ipRecord)...)
+		if err != nil {
+			tx.Rollback()
+			if isUniqueViolation(err) {
+				return fmt.Errorf("event_uuid %s: %w", ipRecord.Event_uuid, ErrDuplicateEvent)
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// isUniqueViolation reports whether the error is a violation of the primary key or a unique constraint of any driver
+func isUniqueViolation(err error) bool {
+	switch e := err.(type) {
+	case sqlite3.Error:
+		return e.ExtendedCode == sqlite3.ErrConstraintPrimaryKey || e.ExtendedCode == sqlite3.ErrConstraintUnique
+	case *pq.Error:
+		return e.Code == "23505"
+	}
+
+	return false
+}
+
+// GetIpAccessRecord is an implementation to get a record by the event uuid
+func (store *sqlAccessStore) GetIpAccessRecord(eventUuid string) (*supermandetector.IpAccessRecord, error) {
+	return store.queryIpAccessRecord("select "+ipAccessColumns+" from ipaccess where event_uuid = ?", eventUuid)
+}
+
+// GetPrecedingIpAccessRecord is an implementation to get a nearest preceding record of the user
+func (store *sqlAccessStore) GetPrecedingIpAccessRecord(username string, timestampMs int64, eventUuid string) (*supermandetector.IpAccessRecord, error) {
+	return store.queryIpAccessRecord("select "+ipAccessColumns+" from ipaccess where username = ? and event_uuid <> ? and (timestamp_ms < ? or (timestamp_ms = ? and event_uuid < ?)) order by timestamp_ms desc, event_uuid desc limit 1", username, eventUuid, timestampMs, timestampMs, eventUuid)
+}
+
+// GetSubsequentIpAccessRecord is an implementation to get a nearest subsequent record of the user
+func (store *sqlAccessStore) GetSubsequentIpAccessRecord(username string, timestampMs int64, eventUuid string) (*supermandetector.IpAccessRecord, error) {
+	return store.queryIpAccessRecord("select "+ipAccessColumns+" from ipaccess where username = ? and event_uuid <> ? and (timestamp_ms > ? or (timestamp_ms = ? and event_uuid > ?)) order by timestamp_ms asc, event_uuid asc limit 1", username, eventUuid, timestampMs, timestampMs, eventUuid)
+}
+
+func (store *sqlAccessStore) queryIpAccessRecord(query string, args ...interface{}) (*supermandetector.IpAccessRecord, error) {
+	stmt, err := store.db.Prepare(store.bind(query))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ipRecord := supermandetector.NewIpAccessRecord()
+	err = stmt.QueryRow(args...).Scan(ipAccessFields(ipRecord)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return ipRecord, nil
+}
+
+// DeleteIpAccessRecord is an implementation to delete a record by the event uuid
+func (store *sqlAccessStore) DeleteIpAccessRecord(eventUuid string) error {
+	_, err := store.db.Exec(store.bind("delete from ipaccess where event_uuid = ?"), eventUuid)
+	return err
+}
+
+// DeleteIpAccessRecords is an implementation to delete all records of the user
+func (store *sqlAccessStore) DeleteIpAccessRecords(username string) (int, error) {
+	result, err := store.db.Exec(store.bind("delete from ipaccess where username = ?"), username)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+
+	return int(n), err
+}
+
+// PurgeIpAccessRecords is an implementation to delete the expired records of the tenant in a batch
+func (store *sqlAccessStore) PurgeIpAccessRecords(tenant string, excluded []string, timestampMs int64, limit int) (int, error) {
+	query := "delete from ipaccess where event_uuid in (select event_uuid from ipaccess where timestamp_ms < ?"
+	args := []interface{}{timestampMs}
+	if tenant != "" {
+		query += " and (principal = ? or principal like ? escape '\\')"
+		args = append(args, tenant, escapeLike(tenant)+".%")
+	}
+	for _, e := range excluded {
+		query += " and not (principal = ? or principal like ? escape '\\')"
+		args = append(args, e, escapeLike(e)+".%")
+	}
+	query += " limit ?)"
+	args = append(args, limit)
+
+	result, err := store.db.Exec(store.bind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+
+	return int(n), err
+}
+
+// escapeLike escapes the wildcards of a like pattern with a backslash
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// ListIpAccessRecords is an implementation to list all records of the user
+func (store *sqlAccessStore) ListIpAccessRecords(username string) ([]*supermandetector.IpAccessRecord, error) {
+	return store.queryIpAccessRecords("select "+ipAccessColumns+" from ipaccess where username = ? order by timestamp_ms asc, event_uuid asc", username)
+}
+
+// ListIpAccessRecordsBetween is an implementation to list the records of the user in a time window page by page
+func (store *sqlAccessStore) ListIpAccessRecordsBetween(username string, timestampMs int64, eventUuid string, endMs int64, limit int) ([]*supermandetector.IpAccessRecord, error) {
+	return store.queryIpAccessRecords("select "+ipAccessColumns+" from ipaccess where username = ? and (timestamp_ms > ? or (timestamp_ms = ? and event_uuid > ?)) and timestamp_ms < ? order by timestamp_ms asc, event_uuid asc limit ?", username, timestampMs, timestampMs, eventUuid, endMs, limit)
+}
+
+func (store *sqlAccessStore) queryIpAccessRecords(query string, args ...interface{}) ([]*supermandetector.IpAccessRecord, error) {
+	rows, err := store.db.Query(store.bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ipRecords := []*supermandetector.IpAccessRecord{}
+	for rows.Next() {
+		ipRecord := supermandetector.NewIpAccessRecord()
+		err = rows.Scan(ipAccessFields(ipRecord)...)
+		if err != nil {
+			return nil, err
+		}
+		ipRecords = append(ipRecords, ipRecord)
+	}
+
+	return ipRecords, rows.Err()
+}
+
+// Close is an implementation to close the database
+func (store *sqlAccessStore) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gitlab.com/cty3000/superman-detector/supermandetector"
+)
+
+func testIpAccessRecords() []*supermandetector.IpAccessRecord {
+	tor, hosting, asn := false, true, int64(14061)
+	return []*supermandetector.IpAccessRecord{
+		&supermandetector.IpAccessRecord{
+			Username:     "bob",
+			Timestamp_ms: 1514851200000,
+			Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e40",
+			Ip_address:   "24.242.71.20",
+			Lat:          30.3773,
+			Lon:          -97.71,
+			Radius:       5,
+		},
+		&supermandetector.IpAccessRecord{
+			Username:     "bob",
+			Timestamp_ms: 1514761200000,
+			Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e42",
+			Ip_address:   "91.207.175.104",
+			Lat:          34.0549,
+			Lon:          -118.2578,
+			Radius:       200,
+		},
+		&supermandetector.IpAccessRecord{
+			Username:       "alice",
+			Timestamp_ms:   1514764800000,
+			Event_uuid:     "85ad929a-db03-4bf4-9541-8f728fa12e41",
+			Ip_address:     "206.81.252.7",
+			Lat:            39.2293,
+			Lon:            -76.6907,
+			Radius:         10,
+			Principal:      "security.collector",
+			Provider:       "override",
+			Label:          "baltimore-office",
+			IsTor:          &tor,
+			IsHosting:      &hosting,
+			Asn:            &asn,
+			AsOrganization: "DIGITALOCEAN-ASN",
+			ConnectionType: "Corporate",
+			CountryCode:    "US",
+			Subdivision:    "Maryland",
+			City:           "Baltimore",
+			TimeZone:       "America/New_York",
+		},
+	}
+}
+
+func TestAccessStore(t *testing.T) {
+	type args struct {
+		driver string
+		dsn    string
+	}
+	type test struct {
+		name       string
+		args       args
+		beforeFunc func()
+		checkFunc  func(AccessStore) error
+		afterFunc  func()
+		wantErr    error
+	}
+	checkFunc := func(store AccessStore) error {
+		records := testIpAccessRecords()
+
+		got, err := store.GetPrecedingIpAccessRecord("bob", 1514764800000, "")
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, records[1]) {
+			return fmt.Errorf("preceding got: %+v, want: %+v", got, records[1])
+		}
+
+		got, err = store.GetSubsequentIpAccessRecord("bob", 1514764800000, "")
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, records[0]) {
+			return fmt.Errorf("subsequent got: %+v, want: %+v", got, records[0])
+		}
+
+		// the stored copy of an event evaluated at another time is not a neighbour of itself
+		got, err = store.GetPrecedingIpAccessRecord("bob", 1514851200001, records[0].Event_uuid)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, records[1]) {
+			return fmt.Errorf("preceding of the same event got: %+v, want: %+v", got, records[1])
+		}
+
+		got, err = store.GetSubsequentIpAccessRecord("bob", 1514761199999, records[1].Event_uuid)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, records[0]) {
+			return fmt.Errorf("subsequent of the same event got: %+v, want: %+v", got, records[0])
+		}
+
+		got, err = store.GetPrecedingIpAccessRecord("alice", 1514764800000, "85ad929a-db03-4bf4-9541-8f728fa12e41")
+		if err != nil {
+			return err
+		}
+		if got != nil {
+			return fmt.Errorf("preceding got: %+v, want: nil", got)
+		}
+
+		if err := store.RegisterIpAccessRecord(records[0]); !errors.Is(err, ErrDuplicateEvent) {
+			return fmt.Errorf("duplicate event_uuid got: %v, want: %v", err, ErrDuplicateEvent)
+		}
+
+		batch := []*supermandetector.IpAccessRecord{
+			&supermandetector.IpAccessRecord{
+				Username:     "carol",
+				Timestamp_ms: 1514764800000,
+				Event_uuid:   "85ad929a-db03-4bf4-9541-8f728fa12e43",
+				Ip_address:   "206.81.252.7",
+			},
+			records[2],
+		}
+		if err := store.RegisterIpAccessRecords(batch); !errors.Is(err, ErrDuplicateEvent) {
+			return fmt.Errorf("duplicate event_uuid in batch got: %v, want: %v", err, ErrDuplicateEvent)
+		}
+		list, err := store.ListIpAccessRecords("carol")
+		if err != nil {
+			return err
+		}
+		if len(list) != 0 {
+			return fmt.Errorf("batch with duplicate event_uuid registered: %+v", list)
+		}
+
+		list, err = store.ListIpAccessRecordsBetween("bob", 1514761200000, "", 1514851200000, 10)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(list, records[1:2]) {
+			return fmt.Errorf("list between got: %+v, want: %+v", list, records[1:2])
+		}
+		list, err = store.ListIpAccessRecordsBetween("bob", 1514761200000, records[1].Event_uuid, math.MaxInt64, 1)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(list, records[0:1]) {
+			return fmt.Errorf("list between got: %+v, want: %+v", list, records[0:1])
+		}
+
+		got, err = store.GetIpAccessRecord(records[2].Event_uuid)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, records[2]) {
+			return fmt.Errorf("got: %+v, want: %+v", got, records[2])
+		}
+		got, err = store.GetIpAccessRecord("unknown")
+		if err != nil {
+			return err
+		}
+		if got != nil {
+			return fmt.Errorf("unknown event_uuid got: %+v, want: nil", got)
+		}
+
+		err = store.DeleteIpAccessRecord(records[0].Event_uuid)
+		if err != nil {
+			return err
+		}
+		list, err = store.ListIpAccessRecords("bob")
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(list, records[1:2]) {
+			return fmt.Errorf("list got: %+v, want: %+v", list, records[1:2])
+		}
+
+		n, err := store.DeleteIpAccessRecords("alice")
+		if err != nil {
+			return err
+		}
+		if n != 1 {
+			return fmt.Errorf("deleted records got: %d, want: 1", n)
+		}
+		got, err = store.GetIpAccessRecord(records[2].Event_uuid)
+		if err != nil {
+			return err
+		}
+		if got != nil {
+			return fmt.Errorf("deleted record got: %+v, want: nil", got)
+		}
+
+		return nil
+	}
+	tests := []test{
+		{
+			name: "Check memory",
+			args: args{
+				driver: "memory",
+			},
+			checkFunc: checkFunc,
+		},
+		{
+			name: "Check sqlite3",
+			args: args{
+				driver: "sqlite3",
+				dsn:    "./ipaccess_test.db",
+			},
+			beforeFunc: func() {
+				os.Remove("./ipaccess_test.db")
+			},
+			checkFunc: checkFunc,
+			afterFunc: func() {
+				os.Remove("./ipaccess_test.db")
+			},
+		},
+		{
+			name: "Check unknown driver",
+			args: args{
+				driver: "unknown",
+			},
+			wantErr: fmt.Errorf("unknown storage driver: unknown"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.beforeFunc != nil {
+				tt.beforeFunc()
+			}
+			if tt.afterFunc != nil {
+				defer tt.afterFunc()
+			}
+
+			store, err := NewAccessStore(tt.args.driver, tt.args.dsn)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("failed to instantiate, error: %v", err)
+				return
+			} else if tt.wantErr != nil {
+				if err == nil {
+					t.Errorf("got nil error, want: %v, got: %v", tt.wantErr, err)
+					return
+				}
+				if tt.wantErr.Error() != err.Error() {
+					t.Errorf("error not the same, want: %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			defer store.Close()
+
+			for _, r := range testIpAccessRecords() {
+				err = store.RegisterIpAccessRecord(r)
+				if err != nil {
+					t.Errorf("failed to register, error: %v", err)
+					return
+				}
+			}
+
+			if tt.checkFunc != nil {
+				err = tt.checkFunc(store)
+				if err != nil {
+					t.Errorf("compare check failed, err: %v", err)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteAccessStorePersistence(t *testing.T) {
+	path := "./ipaccess_persistence_test.db"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	store, err := NewSQLiteAccessStore(path)
+	if err != nil {
+		t.Errorf("failed to instantiate, error: %v", err)
+		return
+	}
+	want := testIpAccessRecords()
+	for _, r := range want {
+		err = store.RegisterIpAccessRecord(r)
+		if err != nil {
+			t.Errorf("failed to register, error: %v", err)
+			return
+		}
+	}
+	store.Close()
+
+	store, err = NewSQLiteAccessStore(path)
+	if err != nil {
+		t.Errorf("failed to reopen, error: %v", err)
+		return
+	}
+	defer store.Close()
+
+	got, err := store.ListIpAccessRecords("bob")
+	if err != nil {
+		t.Errorf("failed to list, error: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(got, []*supermandetector.IpAccessRecord{want[1], want[0]}) {
+		t.Errorf("got: %+v, want: %+v", got, []*supermandetector.IpAccessRecord{want[1], want[0]})
+	}
+}
+
+func TestSQLiteAccessStoreConcurrency(t *testing.T) {
+	type args struct {
+		dsn string
+	}
+	type test struct {
+		name       string
+		args       args
+		beforeFunc func()
+		afterFunc  func()
+	}
+	tests := []test{
+		{
+			name: "Check concurrent writes to memory",
+			args: args{dsn: ":memory:"},
+		},
+		{
+			name: "Check concurrent writes to file",
+			args: args{dsn: "./ipaccess_concurrency_test.db"},
+			beforeFunc: func() {
+				os.Remove("./ipaccess_concurrency_test.db")
+			},
+			afterFunc: func() {
+				os.Remove("./ipaccess_concurrency_test.db")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.beforeFunc != nil {
+				tt.beforeFunc()
+			}
+			if tt.afterFunc != nil {
+				defer tt.afterFunc()
+			}
+
+			store, err := NewSQLiteAccessStore(tt.args.dsn)
+			if err != nil {
+				t.Errorf("failed to instantiate, error: %v", err)
+				return
+			}
+			defer store.Close()
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 20)
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					records := []*supermandetector.IpAccessRecord{}
+					for j := 0; j < 50; j++ {
+						records = append(records, &supermandetector.IpAccessRecord{
+							Username:     "bob",
+							Timestamp_ms: int64(1514764800000 + i*50 + j),
+							Event_uuid:   fmt.Sprintf("85ad929a-db03-4bf4-9541-%012d", i*50+j),
+							Ip_address:   "206.81.252.7",
+						})
+					}
+					if i%2 == 0 {
+						errs <- store.RegisterIpAccessRecords(records)
+						return
+					}
+					for _, r := range records {
+						if err := store.RegisterIpAccessRecord(r); err != nil {
+							errs <- err
+							return
+						}
+					}
+					errs <- nil
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Errorf("failed to register, error: %v", err)
+				}
+			}
+
+			got, err := store.ListIpAccessRecords("bob")
+			if err != nil {
+				t.Errorf("failed to list, error: %v", err)
+				return
+			}
+			if len(got) != 1000 {
+				t.Errorf("registered records got: %d, want: 1000", len(got))
+			}
+		})
+	}
+}
@@ -19,6 +19,21 @@ var _ = fmt.Printf
 //
 type Octet string
 
+//
+// IPv4Address -
+//
+type IPv4Address string
+
+//
+// Hextet -
+//
+type Hextet string
+
+//
+// IPv6Address -
+//
+type IPv6Address string
+
 //
 // IPAddress -
 //
@@ -119,13 +134,92 @@ func (self *IpAccessRequest) Validate() error {
 	return nil
 }
 
+//
+// IpAccessRequestV2 -
+//
+type IpAccessRequestV2 struct {
+	Username     string    `json:"username"`
+	Timestamp_ms int64     `json:"timestamp_ms"`
+	Event_uuid   string    `json:"event_uuid"`
+	Ip_address   IPAddress `json:"ip_address"`
+}
+
+//
+// NewIpAccessRequestV2 - creates an initialized IpAccessRequestV2 instance, returns a pointer to it
+//
+func NewIpAccessRequestV2(init ...*IpAccessRequestV2) *IpAccessRequestV2 {
+	var o *IpAccessRequestV2
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IpAccessRequestV2)
+	}
+	return o
+}
+
+type rawIpAccessRequestV2 IpAccessRequestV2
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a IpAccessRequestV2
+//
+func (self *IpAccessRequestV2) UnmarshalJSON(b []byte) error {
+	var m rawIpAccessRequestV2
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IpAccessRequestV2(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *IpAccessRequestV2) Validate() error {
+	if self.Username == "" {
+		return fmt.Errorf("IpAccessRequestV2.username is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Username)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRequestV2.username does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Event_uuid == "" {
+		return fmt.Errorf("IpAccessRequestV2.event_uuid is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Event_uuid)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRequestV2.event_uuid does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Ip_address == "" {
+		return fmt.Errorf("IpAccessRequestV2.ip_address is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "IPAddress", self.Ip_address)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRequestV2.ip_address does not contain a valid IPAddress (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
 //
 // CurrentGeo -
 //
 type CurrentGeo struct {
-	Lat    float64 `json:"lat"`
-	Lon    float64 `json:"lon"`
-	Radius int32   `json:"radius"`
+	Lat            float64 `json:"lat"`
+	Lon            float64 `json:"lon"`
+	Radius         int32   `json:"radius"`
+	Provider       string  `json:"provider,omitempty" rdl:"optional"`
+	Label          string  `json:"label,omitempty" rdl:"optional"`
+	Asn            *int64  `json:"asn,omitempty" rdl:"optional"`
+	AsOrganization string  `json:"asOrganization,omitempty" rdl:"optional"`
+	ConnectionType string  `json:"connectionType,omitempty" rdl:"optional"`
+	CountryCode    string  `json:"countryCode,omitempty" rdl:"optional"`
+	Subdivision    string  `json:"subdivision,omitempty" rdl:"optional"`
+	City           string  `json:"city,omitempty" rdl:"optional"`
+	TimeZone       string  `json:"timeZone,omitempty" rdl:"optional"`
 }
 
 //
@@ -161,6 +255,54 @@ func (self *CurrentGeo) UnmarshalJSON(b []byte) error {
 // Validate - checks for missing required fields, etc
 //
 func (self *CurrentGeo) Validate() error {
+	if self.Provider != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Provider)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.provider does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Label != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Label)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.label does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.AsOrganization != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.AsOrganization)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.asOrganization does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.ConnectionType != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.ConnectionType)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.connectionType does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.CountryCode != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.CountryCode)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.countryCode does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Subdivision != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Subdivision)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.subdivision does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.City != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.City)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.city does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.TimeZone != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.TimeZone)
+		if !val.Valid {
+			return fmt.Errorf("CurrentGeo.timeZone does not contain a valid String (%v)", val.Error)
+		}
+	}
 	return nil
 }
 
@@ -168,12 +310,28 @@ func (self *CurrentGeo) Validate() error {
 // IpAccess -
 //
 type IpAccess struct {
-	Ip        IPAddress `json:"ip"`
-	Speed     int32     `json:"speed"`
-	Lat       float64   `json:"lat"`
-	Lon       float64   `json:"lon"`
-	Radius    int32     `json:"radius"`
-	Timestamp int32     `json:"timestamp"`
+	Ip       IPAddress `json:"ip"`
+	Speed    int32     `json:"speed"`
+	MinSpeed int32     `json:"minSpeed"`
+	MaxSpeed int32     `json:"maxSpeed"`
+	Lat      float64   `json:"lat"`
+	Lon      float64   `json:"lon"`
+	Radius   int32     `json:"radius"`
+
+	//
+	// seconds of timestampMs for the legacy clients, clamped to the range of
+	// Int32 after 2038-01-19T03:14:07Z; use timestampMs
+	//
+	Timestamp      int32  `json:"timestamp"`
+	TimestampMs    int64  `json:"timestampMs"`
+	Simultaneous   *bool  `json:"simultaneous,omitempty" rdl:"optional"`
+	Asn            *int64 `json:"asn,omitempty" rdl:"optional"`
+	AsOrganization string `json:"asOrganization,omitempty" rdl:"optional"`
+	ConnectionType string `json:"connectionType,omitempty" rdl:"optional"`
+	CountryCode    string `json:"countryCode,omitempty" rdl:"optional"`
+	Subdivision    string `json:"subdivision,omitempty" rdl:"optional"`
+	City           string `json:"city,omitempty" rdl:"optional"`
+	TimeZone       string `json:"timeZone,omitempty" rdl:"optional"`
 }
 
 //
@@ -217,6 +375,42 @@ func (self *IpAccess) Validate() error {
 			return fmt.Errorf("IpAccess.ip does not contain a valid IPAddress (%v)", val.Error)
 		}
 	}
+	if self.AsOrganization != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.AsOrganization)
+		if !val.Valid {
+			return fmt.Errorf("IpAccess.asOrganization does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.ConnectionType != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.ConnectionType)
+		if !val.Valid {
+			return fmt.Errorf("IpAccess.connectionType does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.CountryCode != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.CountryCode)
+		if !val.Valid {
+			return fmt.Errorf("IpAccess.countryCode does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Subdivision != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Subdivision)
+		if !val.Valid {
+			return fmt.Errorf("IpAccess.subdivision does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.City != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.City)
+		if !val.Valid {
+			return fmt.Errorf("IpAccess.city does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.TimeZone != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.TimeZone)
+		if !val.Valid {
+			return fmt.Errorf("IpAccess.timeZone does not contain a valid String (%v)", val.Error)
+		}
+	}
 	return nil
 }
 
@@ -229,6 +423,10 @@ type IpAccessResponse struct {
 	TravelFromCurrentGeoSuspicious *bool       `json:"travelFromCurrentGeoSuspicious,omitempty" rdl:"optional"`
 	PrecedingIpAccess              *IpAccess   `json:"precedingIpAccess,omitempty" rdl:"optional"`
 	SubsequentIpAccess             *IpAccess   `json:"subsequentIpAccess,omitempty" rdl:"optional"`
+	IsTor                          *bool       `json:"isTor,omitempty" rdl:"optional"`
+	IsAnonymizer                   *bool       `json:"isAnonymizer,omitempty" rdl:"optional"`
+	IsHosting                      *bool       `json:"isHosting,omitempty" rdl:"optional"`
+	NetworkSuspicious              *bool       `json:"networkSuspicious,omitempty" rdl:"optional"`
 }
 
 //
@@ -280,17 +478,256 @@ func (self *IpAccessResponse) Validate() error {
 	return nil
 }
 
+//
+// ServiceError -
+//
+type ServiceError struct {
+	Code      int32  `json:"code"`
+	ErrorCode string `json:"errorCode"`
+	Message   string `json:"message"`
+}
+
+//
+// NewServiceError - creates an initialized ServiceError instance, returns a pointer to it
+//
+func NewServiceError(init ...*ServiceError) *ServiceError {
+	var o *ServiceError
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(ServiceError)
+	}
+	return o
+}
+
+type rawServiceError ServiceError
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a ServiceError
+//
+func (self *ServiceError) UnmarshalJSON(b []byte) error {
+	var m rawServiceError
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := ServiceError(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *ServiceError) Validate() error {
+	if self.ErrorCode == "" {
+		return fmt.Errorf("ServiceError.errorCode is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.ErrorCode)
+		if !val.Valid {
+			return fmt.Errorf("ServiceError.errorCode does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Message == "" {
+		return fmt.Errorf("ServiceError.message is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Message)
+		if !val.Valid {
+			return fmt.Errorf("ServiceError.message does not contain a valid String (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+//
+// IpAccessBatchRequest -
+//
+type IpAccessBatchRequest struct {
+	Requests []*IpAccessRequestV2 `json:"requests"`
+}
+
+//
+// NewIpAccessBatchRequest - creates an initialized IpAccessBatchRequest instance, returns a pointer to it
+//
+func NewIpAccessBatchRequest(init ...*IpAccessBatchRequest) *IpAccessBatchRequest {
+	var o *IpAccessBatchRequest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IpAccessBatchRequest)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *IpAccessBatchRequest) Init() *IpAccessBatchRequest {
+	if self.Requests == nil {
+		self.Requests = make([]*IpAccessRequestV2, 0)
+	}
+	return self
+}
+
+type rawIpAccessBatchRequest IpAccessBatchRequest
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a IpAccessBatchRequest
+//
+func (self *IpAccessBatchRequest) UnmarshalJSON(b []byte) error {
+	var m rawIpAccessBatchRequest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IpAccessBatchRequest(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *IpAccessBatchRequest) Validate() error {
+	if self.Requests == nil {
+		return fmt.Errorf("IpAccessBatchRequest: Missing required field: requests")
+	}
+	return nil
+}
+
+//
+// IpAccessBatchResult -
+//
+type IpAccessBatchResult struct {
+	Event_uuid string            `json:"event_uuid"`
+	Response   *IpAccessResponse `json:"response,omitempty" rdl:"optional"`
+	Error      *ServiceError     `json:"error,omitempty" rdl:"optional"`
+}
+
+//
+// NewIpAccessBatchResult - creates an initialized IpAccessBatchResult instance, returns a pointer to it
+//
+func NewIpAccessBatchResult(init ...*IpAccessBatchResult) *IpAccessBatchResult {
+	var o *IpAccessBatchResult
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IpAccessBatchResult)
+	}
+	return o
+}
+
+type rawIpAccessBatchResult IpAccessBatchResult
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a IpAccessBatchResult
+//
+func (self *IpAccessBatchResult) UnmarshalJSON(b []byte) error {
+	var m rawIpAccessBatchResult
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IpAccessBatchResult(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *IpAccessBatchResult) Validate() error {
+	if self.Event_uuid == "" {
+		return fmt.Errorf("IpAccessBatchResult.event_uuid is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Event_uuid)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessBatchResult.event_uuid does not contain a valid String (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+//
+// IpAccessBatchResponse -
+//
+type IpAccessBatchResponse struct {
+	Results []*IpAccessBatchResult `json:"results"`
+}
+
+//
+// NewIpAccessBatchResponse - creates an initialized IpAccessBatchResponse instance, returns a pointer to it
+//
+func NewIpAccessBatchResponse(init ...*IpAccessBatchResponse) *IpAccessBatchResponse {
+	var o *IpAccessBatchResponse
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IpAccessBatchResponse)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *IpAccessBatchResponse) Init() *IpAccessBatchResponse {
+	if self.Results == nil {
+		self.Results = make([]*IpAccessBatchResult, 0)
+	}
+	return self
+}
+
+type rawIpAccessBatchResponse IpAccessBatchResponse
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a IpAccessBatchResponse
+//
+func (self *IpAccessBatchResponse) UnmarshalJSON(b []byte) error {
+	var m rawIpAccessBatchResponse
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IpAccessBatchResponse(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *IpAccessBatchResponse) Validate() error {
+	if self.Results == nil {
+		return fmt.Errorf("IpAccessBatchResponse: Missing required field: results")
+	}
+	return nil
+}
+
 //
 // IpAccessRecord -
 //
 type IpAccessRecord struct {
 	Username       string    `json:"username"`
-	Unix_timestamp int32     `json:"unix_timestamp"`
+	Timestamp_ms   int64     `json:"timestamp_ms"`
 	Event_uuid     string    `json:"event_uuid"`
 	Ip_address     IPAddress `json:"ip_address"`
 	Lat            float64   `json:"lat"`
 	Lon            float64   `json:"lon"`
 	Radius         int32     `json:"radius"`
+	Principal      string    `json:"principal,omitempty" rdl:"optional"`
+	Provider       string    `json:"provider,omitempty" rdl:"optional"`
+	Label          string    `json:"label,omitempty" rdl:"optional"`
+	IsTor          *bool     `json:"isTor,omitempty" rdl:"optional"`
+	IsAnonymizer   *bool     `json:"isAnonymizer,omitempty" rdl:"optional"`
+	IsHosting      *bool     `json:"isHosting,omitempty" rdl:"optional"`
+	Asn            *int64    `json:"asn,omitempty" rdl:"optional"`
+	AsOrganization string    `json:"asOrganization,omitempty" rdl:"optional"`
+	ConnectionType string    `json:"connectionType,omitempty" rdl:"optional"`
+	CountryCode    string    `json:"countryCode,omitempty" rdl:"optional"`
+	Subdivision    string    `json:"subdivision,omitempty" rdl:"optional"`
+	City           string    `json:"city,omitempty" rdl:"optional"`
+	TimeZone       string    `json:"timeZone,omitempty" rdl:"optional"`
 }
 
 //
@@ -350,5 +787,940 @@ func (self *IpAccessRecord) Validate() error {
 			return fmt.Errorf("IpAccessRecord.ip_address does not contain a valid IPAddress (%v)", val.Error)
 		}
 	}
+	if self.Principal != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Principal)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.principal does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Provider != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Provider)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.provider does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Label != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Label)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.label does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.AsOrganization != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.AsOrganization)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.asOrganization does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.ConnectionType != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.ConnectionType)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.connectionType does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.CountryCode != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.CountryCode)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.countryCode does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Subdivision != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Subdivision)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.subdivision does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.City != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.City)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.city does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.TimeZone != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.TimeZone)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessRecord.timeZone does not contain a valid String (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+//
+// IpAccessTimelineEntry -
+//
+type IpAccessTimelineEntry struct {
+	Record                         *IpAccessRecord `json:"record"`
+	TravelToCurrentGeoSuspicious   *bool           `json:"travelToCurrentGeoSuspicious,omitempty" rdl:"optional"`
+	TravelFromCurrentGeoSuspicious *bool           `json:"travelFromCurrentGeoSuspicious,omitempty" rdl:"optional"`
+	PrecedingIpAccess              *IpAccess       `json:"precedingIpAccess,omitempty" rdl:"optional"`
+	SubsequentIpAccess             *IpAccess       `json:"subsequentIpAccess,omitempty" rdl:"optional"`
+}
+
+//
+// NewIpAccessTimelineEntry - creates an initialized IpAccessTimelineEntry instance, returns a pointer to it
+//
+func NewIpAccessTimelineEntry(init ...*IpAccessTimelineEntry) *IpAccessTimelineEntry {
+	var o *IpAccessTimelineEntry
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IpAccessTimelineEntry)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *IpAccessTimelineEntry) Init() *IpAccessTimelineEntry {
+	if self.Record == nil {
+		self.Record = NewIpAccessRecord()
+	}
+	return self
+}
+
+type rawIpAccessTimelineEntry IpAccessTimelineEntry
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a IpAccessTimelineEntry
+//
+func (self *IpAccessTimelineEntry) UnmarshalJSON(b []byte) error {
+	var m rawIpAccessTimelineEntry
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IpAccessTimelineEntry(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *IpAccessTimelineEntry) Validate() error {
+	if self.Record == nil {
+		return fmt.Errorf("IpAccessTimelineEntry: Missing required field: record")
+	}
+	return nil
+}
+
+//
+// IpAccessTimeline -
+//
+type IpAccessTimeline struct {
+	Username string                   `json:"username"`
+	Accesses []*IpAccessTimelineEntry `json:"accesses"`
+	Next     string                   `json:"next,omitempty" rdl:"optional"`
+}
+
+//
+// NewIpAccessTimeline - creates an initialized IpAccessTimeline instance, returns a pointer to it
+//
+func NewIpAccessTimeline(init ...*IpAccessTimeline) *IpAccessTimeline {
+	var o *IpAccessTimeline
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IpAccessTimeline)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *IpAccessTimeline) Init() *IpAccessTimeline {
+	if self.Accesses == nil {
+		self.Accesses = make([]*IpAccessTimelineEntry, 0)
+	}
+	return self
+}
+
+type rawIpAccessTimeline IpAccessTimeline
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a IpAccessTimeline
+//
+func (self *IpAccessTimeline) UnmarshalJSON(b []byte) error {
+	var m rawIpAccessTimeline
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IpAccessTimeline(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *IpAccessTimeline) Validate() error {
+	if self.Username == "" {
+		return fmt.Errorf("IpAccessTimeline.username is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Username)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessTimeline.username does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Accesses == nil {
+		return fmt.Errorf("IpAccessTimeline: Missing required field: accesses")
+	}
+	if self.Next != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Next)
+		if !val.Valid {
+			return fmt.Errorf("IpAccessTimeline.next does not contain a valid String (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+//
+// IpAccessEvent -
+//
+type IpAccessEvent struct {
+	Record  *IpAccessRecord   `json:"record"`
+	Verdict *IpAccessResponse `json:"verdict"`
+}
+
+//
+// NewIpAccessEvent - creates an initialized IpAccessEvent instance, returns a pointer to it
+//
+func NewIpAccessEvent(init ...*IpAccessEvent) *IpAccessEvent {
+	var o *IpAccessEvent
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IpAccessEvent)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *IpAccessEvent) Init() *IpAccessEvent {
+	if self.Record == nil {
+		self.Record = NewIpAccessRecord()
+	}
+	if self.Verdict == nil {
+		self.Verdict = NewIpAccessResponse()
+	}
+	return self
+}
+
+type rawIpAccessEvent IpAccessEvent
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a IpAccessEvent
+//
+func (self *IpAccessEvent) UnmarshalJSON(b []byte) error {
+	var m rawIpAccessEvent
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IpAccessEvent(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *IpAccessEvent) Validate() error {
+	if self.Record == nil {
+		return fmt.Errorf("IpAccessEvent: Missing required field: record")
+	}
+	if self.Verdict == nil {
+		return fmt.Errorf("IpAccessEvent: Missing required field: verdict")
+	}
+	return nil
+}
+
+//
+// SpeedUnit -
+//
+type SpeedUnit int
+
+//
+// SpeedUnit constants
+//
+const (
+	_ SpeedUnit = iota
+	MPH
+	KMH
+)
+
+var namesSpeedUnit = []string{
+	MPH: "MPH",
+	KMH: "KMH",
+}
+
+//
+// NewSpeedUnit - return a string representation of the enum
+//
+func NewSpeedUnit(init ...interface{}) SpeedUnit {
+	if len(init) == 1 {
+		switch v := init[0].(type) {
+		case SpeedUnit:
+			return v
+		case int:
+			return SpeedUnit(v)
+		case int32:
+			return SpeedUnit(v)
+		case string:
+			for i, s := range namesSpeedUnit {
+				if s == v {
+					return SpeedUnit(i)
+				}
+			}
+		default:
+			panic("Bad init value for SpeedUnit enum")
+		}
+	}
+	return SpeedUnit(0) //default to the first enum value
+}
+
+//
+// String - return a string representation of the enum
+//
+func (e SpeedUnit) String() string {
+	return namesSpeedUnit[e]
+}
+
+//
+// SymbolSet - return an array of all valid string representations (symbols) of the enum
+//
+func (e SpeedUnit) SymbolSet() []string {
+	return namesSpeedUnit
+}
+
+//
+// MarshalJSON is defined for proper JSON encoding of a SpeedUnit
+//
+func (e SpeedUnit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a SpeedUnit
+//
+func (e *SpeedUnit) UnmarshalJSON(b []byte) error {
+	var j string
+	err := json.Unmarshal(b, &j)
+	if err == nil {
+		s := string(j)
+		for v, s2 := range namesSpeedUnit {
+			if s == s2 {
+				*e = SpeedUnit(v)
+				return nil
+			}
+		}
+		err = fmt.Errorf("Bad enum symbol for type SpeedUnit: %s", s)
+	}
+	return err
+}
+
+//
+// SpeedPolicy -
+//
+type SpeedPolicy struct {
+	Threshold float64   `json:"threshold"`
+	Unit      SpeedUnit `json:"unit"`
+}
+
+//
+// NewSpeedPolicy - creates an initialized SpeedPolicy instance, returns a pointer to it
+//
+func NewSpeedPolicy(init ...*SpeedPolicy) *SpeedPolicy {
+	var o *SpeedPolicy
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(SpeedPolicy)
+	}
+	return o
+}
+
+type rawSpeedPolicy SpeedPolicy
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a SpeedPolicy
+//
+func (self *SpeedPolicy) UnmarshalJSON(b []byte) error {
+	var m rawSpeedPolicy
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := SpeedPolicy(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *SpeedPolicy) Validate() error {
+	return nil
+}
+
+//
+// SpeedPolicyGroup -
+//
+type SpeedPolicyGroup struct {
+	Name      string       `json:"name"`
+	Policy    *SpeedPolicy `json:"policy"`
+	Usernames []string     `json:"usernames"`
+}
+
+//
+// NewSpeedPolicyGroup - creates an initialized SpeedPolicyGroup instance, returns a pointer to it
+//
+func NewSpeedPolicyGroup(init ...*SpeedPolicyGroup) *SpeedPolicyGroup {
+	var o *SpeedPolicyGroup
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(SpeedPolicyGroup)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *SpeedPolicyGroup) Init() *SpeedPolicyGroup {
+	if self.Policy == nil {
+		self.Policy = NewSpeedPolicy()
+	}
+	if self.Usernames == nil {
+		self.Usernames = make([]string, 0)
+	}
+	return self
+}
+
+type rawSpeedPolicyGroup SpeedPolicyGroup
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a SpeedPolicyGroup
+//
+func (self *SpeedPolicyGroup) UnmarshalJSON(b []byte) error {
+	var m rawSpeedPolicyGroup
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := SpeedPolicyGroup(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *SpeedPolicyGroup) Validate() error {
+	if self.Name == "" {
+		return fmt.Errorf("SpeedPolicyGroup.name is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Name)
+		if !val.Valid {
+			return fmt.Errorf("SpeedPolicyGroup.name does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Policy == nil {
+		return fmt.Errorf("SpeedPolicyGroup: Missing required field: policy")
+	}
+	if self.Usernames == nil {
+		return fmt.Errorf("SpeedPolicyGroup: Missing required field: usernames")
+	}
+	return nil
+}
+
+//
+// SpeedPolicies -
+//
+type SpeedPolicies struct {
+	DefaultPolicy *SpeedPolicy            `json:"defaultPolicy"`
+	Groups        []*SpeedPolicyGroup     `json:"groups"`
+	Users         map[string]*SpeedPolicy `json:"users"`
+}
+
+//
+// NewSpeedPolicies - creates an initialized SpeedPolicies instance, returns a pointer to it
+//
+func NewSpeedPolicies(init ...*SpeedPolicies) *SpeedPolicies {
+	var o *SpeedPolicies
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(SpeedPolicies)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *SpeedPolicies) Init() *SpeedPolicies {
+	if self.DefaultPolicy == nil {
+		self.DefaultPolicy = NewSpeedPolicy()
+	}
+	if self.Groups == nil {
+		self.Groups = make([]*SpeedPolicyGroup, 0)
+	}
+	if self.Users == nil {
+		self.Users = make(map[string]*SpeedPolicy)
+	}
+	return self
+}
+
+type rawSpeedPolicies SpeedPolicies
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a SpeedPolicies
+//
+func (self *SpeedPolicies) UnmarshalJSON(b []byte) error {
+	var m rawSpeedPolicies
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := SpeedPolicies(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *SpeedPolicies) Validate() error {
+	if self.DefaultPolicy == nil {
+		return fmt.Errorf("SpeedPolicies: Missing required field: defaultPolicy")
+	}
+	if self.Groups == nil {
+		return fmt.Errorf("SpeedPolicies: Missing required field: groups")
+	}
+	if self.Users == nil {
+		return fmt.Errorf("SpeedPolicies: Missing required field: users")
+	}
+	return nil
+}
+
+//
+// UserDataExport -
+//
+type UserDataExport struct {
+	Username string            `json:"username"`
+	Records  []*IpAccessRecord `json:"records"`
+	Policy   *SpeedPolicy      `json:"policy,omitempty" rdl:"optional"`
+	Groups   []string          `json:"groups"`
+}
+
+//
+// NewUserDataExport - creates an initialized UserDataExport instance, returns a pointer to it
+//
+func NewUserDataExport(init ...*UserDataExport) *UserDataExport {
+	var o *UserDataExport
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(UserDataExport)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *UserDataExport) Init() *UserDataExport {
+	if self.Records == nil {
+		self.Records = make([]*IpAccessRecord, 0)
+	}
+	if self.Groups == nil {
+		self.Groups = make([]string, 0)
+	}
+	return self
+}
+
+type rawUserDataExport UserDataExport
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a UserDataExport
+//
+func (self *UserDataExport) UnmarshalJSON(b []byte) error {
+	var m rawUserDataExport
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := UserDataExport(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *UserDataExport) Validate() error {
+	if self.Username == "" {
+		return fmt.Errorf("UserDataExport.username is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Username)
+		if !val.Valid {
+			return fmt.Errorf("UserDataExport.username does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Records == nil {
+		return fmt.Errorf("UserDataExport: Missing required field: records")
+	}
+	if self.Groups == nil {
+		return fmt.Errorf("UserDataExport: Missing required field: groups")
+	}
+	return nil
+}
+
+//
+// UserDataErasure -
+//
+type UserDataErasure struct {
+	Username string   `json:"username"`
+	Records  int32    `json:"records"`
+	Policy   bool     `json:"policy"`
+	Groups   []string `json:"groups"`
+}
+
+//
+// NewUserDataErasure - creates an initialized UserDataErasure instance, returns a pointer to it
+//
+func NewUserDataErasure(init ...*UserDataErasure) *UserDataErasure {
+	var o *UserDataErasure
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(UserDataErasure)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *UserDataErasure) Init() *UserDataErasure {
+	if self.Groups == nil {
+		self.Groups = make([]string, 0)
+	}
+	return self
+}
+
+type rawUserDataErasure UserDataErasure
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a UserDataErasure
+//
+func (self *UserDataErasure) UnmarshalJSON(b []byte) error {
+	var m rawUserDataErasure
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := UserDataErasure(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *UserDataErasure) Validate() error {
+	if self.Username == "" {
+		return fmt.Errorf("UserDataErasure.username is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Username)
+		if !val.Valid {
+			return fmt.Errorf("UserDataErasure.username does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.Groups == nil {
+		return fmt.Errorf("UserDataErasure: Missing required field: groups")
+	}
+	return nil
+}
+
+//
+// RetentionStatus -
+//
+type RetentionStatus struct {
+	Retention       string            `json:"retention,omitempty" rdl:"optional"`
+	TenantRetention map[string]string `json:"tenantRetention"`
+	Purged          int64             `json:"purged"`
+	PurgedByTenant  map[string]int64  `json:"purgedByTenant"`
+	Runs            int64             `json:"runs"`
+	LastRun         *rdl.Timestamp    `json:"lastRun,omitempty" rdl:"optional"`
+	LastError       string            `json:"lastError,omitempty" rdl:"optional"`
+}
+
+//
+// NewRetentionStatus - creates an initialized RetentionStatus instance, returns a pointer to it
+//
+func NewRetentionStatus(init ...*RetentionStatus) *RetentionStatus {
+	var o *RetentionStatus
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(RetentionStatus)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *RetentionStatus) Init() *RetentionStatus {
+	if self.TenantRetention == nil {
+		self.TenantRetention = make(map[string]string)
+	}
+	if self.PurgedByTenant == nil {
+		self.PurgedByTenant = make(map[string]int64)
+	}
+	return self
+}
+
+type rawRetentionStatus RetentionStatus
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a RetentionStatus
+//
+func (self *RetentionStatus) UnmarshalJSON(b []byte) error {
+	var m rawRetentionStatus
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := RetentionStatus(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *RetentionStatus) Validate() error {
+	if self.Retention != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Retention)
+		if !val.Valid {
+			return fmt.Errorf("RetentionStatus.retention does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.TenantRetention == nil {
+		return fmt.Errorf("RetentionStatus: Missing required field: tenantRetention")
+	}
+	if self.PurgedByTenant == nil {
+		return fmt.Errorf("RetentionStatus: Missing required field: purgedByTenant")
+	}
+	if self.LastError != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.LastError)
+		if !val.Valid {
+			return fmt.Errorf("RetentionStatus.lastError does not contain a valid String (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+//
+// GeoDBStatus -
+//
+type GeoDBStatus struct {
+	Path         string        `json:"path"`
+	DatabaseType string        `json:"databaseType"`
+	BuildEpoch   int64         `json:"buildEpoch"`
+	LoadedAt     rdl.Timestamp `json:"loadedAt"`
+	LastError    string        `json:"lastError,omitempty" rdl:"optional"`
+}
+
+//
+// NewGeoDBStatus - creates an initialized GeoDBStatus instance, returns a pointer to it
+//
+func NewGeoDBStatus(init ...*GeoDBStatus) *GeoDBStatus {
+	var o *GeoDBStatus
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(GeoDBStatus)
+	}
+	return o
+}
+
+type rawGeoDBStatus GeoDBStatus
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a GeoDBStatus
+//
+func (self *GeoDBStatus) UnmarshalJSON(b []byte) error {
+	var m rawGeoDBStatus
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := GeoDBStatus(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *GeoDBStatus) Validate() error {
+	if self.Path == "" {
+		return fmt.Errorf("GeoDBStatus.path is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Path)
+		if !val.Valid {
+			return fmt.Errorf("GeoDBStatus.path does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.DatabaseType == "" {
+		return fmt.Errorf("GeoDBStatus.databaseType is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.DatabaseType)
+		if !val.Valid {
+			return fmt.Errorf("GeoDBStatus.databaseType does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.LoadedAt.IsZero() {
+		return fmt.Errorf("GeoDBStatus: Missing required field: loadedAt")
+	}
+	if self.LastError != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.LastError)
+		if !val.Valid {
+			return fmt.Errorf("GeoDBStatus.lastError does not contain a valid String (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+//
+// GeoOverrideStatus -
+//
+type GeoOverrideStatus struct {
+	Path      string        `json:"path"`
+	Entries   int32         `json:"entries"`
+	LoadedAt  rdl.Timestamp `json:"loadedAt"`
+	LastError string        `json:"lastError,omitempty" rdl:"optional"`
+}
+
+//
+// NewGeoOverrideStatus - creates an initialized GeoOverrideStatus instance, returns a pointer to it
+//
+func NewGeoOverrideStatus(init ...*GeoOverrideStatus) *GeoOverrideStatus {
+	var o *GeoOverrideStatus
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(GeoOverrideStatus)
+	}
+	return o
+}
+
+type rawGeoOverrideStatus GeoOverrideStatus
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a GeoOverrideStatus
+//
+func (self *GeoOverrideStatus) UnmarshalJSON(b []byte) error {
+	var m rawGeoOverrideStatus
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := GeoOverrideStatus(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *GeoOverrideStatus) Validate() error {
+	if self.Path == "" {
+		return fmt.Errorf("GeoOverrideStatus.path is missing but is a required field")
+	} else {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.Path)
+		if !val.Valid {
+			return fmt.Errorf("GeoOverrideStatus.path does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.LoadedAt.IsZero() {
+		return fmt.Errorf("GeoOverrideStatus: Missing required field: loadedAt")
+	}
+	if self.LastError != "" {
+		val := rdl.Validate(SupermanDetectorSchema(), "String", self.LastError)
+		if !val.Valid {
+			return fmt.Errorf("GeoOverrideStatus.lastError does not contain a valid String (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+//
+// ServiceStatus -
+//
+type ServiceStatus struct {
+	GeoProviders     []string           `json:"geoProviders"`
+	Geodb            *GeoDBStatus       `json:"geodb,omitempty" rdl:"optional"`
+	AnonymousIpDb    *GeoDBStatus       `json:"anonymousIpDb,omitempty" rdl:"optional"`
+	AsnDb            *GeoDBStatus       `json:"asnDb,omitempty" rdl:"optional"`
+	ConnectionTypeDb *GeoDBStatus       `json:"connectionTypeDb,omitempty" rdl:"optional"`
+	Overrides        *GeoOverrideStatus `json:"overrides,omitempty" rdl:"optional"`
+}
+
+//
+// NewServiceStatus - creates an initialized ServiceStatus instance, returns a pointer to it
+//
+func NewServiceStatus(init ...*ServiceStatus) *ServiceStatus {
+	var o *ServiceStatus
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(ServiceStatus)
+	}
+	return o.Init()
+}
+
+//
+// Init - sets up the instance according to its default field values, if any
+//
+func (self *ServiceStatus) Init() *ServiceStatus {
+	if self.GeoProviders == nil {
+		self.GeoProviders = make([]string, 0)
+	}
+	return self
+}
+
+type rawServiceStatus ServiceStatus
+
+//
+// UnmarshalJSON is defined for proper JSON decoding of a ServiceStatus
+//
+func (self *ServiceStatus) UnmarshalJSON(b []byte) error {
+	var m rawServiceStatus
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := ServiceStatus(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+//
+// Validate - checks for missing required fields, etc
+//
+func (self *ServiceStatus) Validate() error {
+	if self.GeoProviders == nil {
+		return fmt.Errorf("ServiceStatus: Missing required field: geoProviders")
+	}
 	return nil
 }
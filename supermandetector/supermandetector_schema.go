@@ -19,11 +19,23 @@ func init() {
 	sb.Comment("A SupermanDetector API in *RDL*")
 
 	tOctet := rdl.NewStringTypeBuilder("Octet")
-	tOctet.Pattern("[0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5]")
+	tOctet.Pattern("(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])")
 	sb.AddType(tOctet.Build())
 
+	tIPv4Address := rdl.NewStringTypeBuilder("IPv4Address")
+	tIPv4Address.Pattern("(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3}")
+	sb.AddType(tIPv4Address.Build())
+
+	tHextet := rdl.NewStringTypeBuilder("Hextet")
+	tHextet.Pattern("[0-9a-fA-F]{1,4}")
+	sb.AddType(tHextet.Build())
+
+	tIPv6Address := rdl.NewStringTypeBuilder("IPv6Address")
+	tIPv6Address.Pattern("(([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,7}:|([0-9a-fA-F]{1,4}:){1,6}:[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,5}(:[0-9a-fA-F]{1,4}){1,2}|([0-9a-fA-F]{1,4}:){1,4}(:[0-9a-fA-F]{1,4}){1,3}|([0-9a-fA-F]{1,4}:){1,3}(:[0-9a-fA-F]{1,4}){1,4}|([0-9a-fA-F]{1,4}:){1,2}(:[0-9a-fA-F]{1,4}){1,5}|[0-9a-fA-F]{1,4}:(:[0-9a-fA-F]{1,4}){1,6}|:((:[0-9a-fA-F]{1,4}){1,7}|:)|([0-9a-fA-F]{1,4}:){6}(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3}|::([fF]{4}(:0{1,4})?:)?(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3}|([0-9a-fA-F]{1,4}:){1,5}:(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3})")
+	sb.AddType(tIPv6Address.Build())
+
 	tIPAddress := rdl.NewStringTypeBuilder("IPAddress")
-	tIPAddress.Pattern("[0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5]\\.[0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5]\\.[0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5]\\.[0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5]")
+	tIPAddress.Pattern("((25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3}|(([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,7}:|([0-9a-fA-F]{1,4}:){1,6}:[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,5}(:[0-9a-fA-F]{1,4}){1,2}|([0-9a-fA-F]{1,4}:){1,4}(:[0-9a-fA-F]{1,4}){1,3}|([0-9a-fA-F]{1,4}:){1,3}(:[0-9a-fA-F]{1,4}){1,4}|([0-9a-fA-F]{1,4}:){1,2}(:[0-9a-fA-F]{1,4}){1,5}|[0-9a-fA-F]{1,4}:(:[0-9a-fA-F]{1,4}){1,6}|:((:[0-9a-fA-F]{1,4}){1,7}|:)|([0-9a-fA-F]{1,4}:){6}(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3}|::([fF]{4}(:0{1,4})?:)?(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3}|([0-9a-fA-F]{1,4}:){1,5}:(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])(\\.(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])){3}))")
 	sb.AddType(tIPAddress.Build())
 
 	tUnixTimestamp := rdl.NewAliasTypeBuilder("Int32", "UnixTimestamp")
@@ -48,19 +60,46 @@ func init() {
 	tIpAccessRequest.Field("ip_address", "IPAddress", false, nil, "")
 	sb.AddType(tIpAccessRequest.Build())
 
+	tIpAccessRequestV2 := rdl.NewStructTypeBuilder("Struct", "IpAccessRequestV2")
+	tIpAccessRequestV2.Field("username", "String", false, nil, "")
+	tIpAccessRequestV2.Field("timestamp_ms", "Int64", false, nil, "")
+	tIpAccessRequestV2.Field("event_uuid", "String", false, nil, "")
+	tIpAccessRequestV2.Field("ip_address", "IPAddress", false, nil, "")
+	sb.AddType(tIpAccessRequestV2.Build())
+
 	tCurrentGeo := rdl.NewStructTypeBuilder("Struct", "CurrentGeo")
 	tCurrentGeo.Field("lat", "Float64", false, nil, "")
 	tCurrentGeo.Field("lon", "Float64", false, nil, "")
 	tCurrentGeo.Field("radius", "Int32", false, nil, "")
+	tCurrentGeo.Field("provider", "String", true, nil, "")
+	tCurrentGeo.Field("label", "String", true, nil, "")
+	tCurrentGeo.Field("asn", "Int64", true, nil, "")
+	tCurrentGeo.Field("asOrganization", "String", true, nil, "")
+	tCurrentGeo.Field("connectionType", "String", true, nil, "")
+	tCurrentGeo.Field("countryCode", "String", true, nil, "")
+	tCurrentGeo.Field("subdivision", "String", true, nil, "")
+	tCurrentGeo.Field("city", "String", true, nil, "")
+	tCurrentGeo.Field("timeZone", "String", true, nil, "")
 	sb.AddType(tCurrentGeo.Build())
 
 	tIpAccess := rdl.NewStructTypeBuilder("Struct", "IpAccess")
 	tIpAccess.Field("ip", "IPAddress", false, nil, "")
 	tIpAccess.Field("speed", "Int32", false, nil, "")
+	tIpAccess.Field("minSpeed", "Int32", false, nil, "")
+	tIpAccess.Field("maxSpeed", "Int32", false, nil, "")
 	tIpAccess.Field("lat", "Float64", false, nil, "")
 	tIpAccess.Field("lon", "Float64", false, nil, "")
 	tIpAccess.Field("radius", "Int32", false, nil, "")
-	tIpAccess.Field("timestamp", "Int32", false, nil, "")
+	tIpAccess.Field("timestamp", "Int32", false, nil, "seconds of timestampMs for the legacy clients, clamped to the range of Int32 after 2038-01-19T03:14:07Z; use timestampMs")
+	tIpAccess.Field("timestampMs", "Int64", false, nil, "")
+	tIpAccess.Field("simultaneous", "Bool", true, nil, "")
+	tIpAccess.Field("asn", "Int64", true, nil, "")
+	tIpAccess.Field("asOrganization", "String", true, nil, "")
+	tIpAccess.Field("connectionType", "String", true, nil, "")
+	tIpAccess.Field("countryCode", "String", true, nil, "")
+	tIpAccess.Field("subdivision", "String", true, nil, "")
+	tIpAccess.Field("city", "String", true, nil, "")
+	tIpAccess.Field("timeZone", "String", true, nil, "")
 	sb.AddType(tIpAccess.Build())
 
 	tIpAccessResponse := rdl.NewStructTypeBuilder("Struct", "IpAccessResponse")
@@ -69,25 +108,295 @@ func init() {
 	tIpAccessResponse.Field("travelFromCurrentGeoSuspicious", "Bool", true, nil, "")
 	tIpAccessResponse.Field("precedingIpAccess", "IpAccess", true, nil, "")
 	tIpAccessResponse.Field("subsequentIpAccess", "IpAccess", true, nil, "")
+	tIpAccessResponse.Field("isTor", "Bool", true, nil, "")
+	tIpAccessResponse.Field("isAnonymizer", "Bool", true, nil, "")
+	tIpAccessResponse.Field("isHosting", "Bool", true, nil, "")
+	tIpAccessResponse.Field("networkSuspicious", "Bool", true, nil, "")
 	sb.AddType(tIpAccessResponse.Build())
 
+	tServiceError := rdl.NewStructTypeBuilder("Struct", "ServiceError")
+	tServiceError.Field("code", "Int32", false, nil, "")
+	tServiceError.Field("errorCode", "String", false, nil, "")
+	tServiceError.Field("message", "String", false, nil, "")
+	sb.AddType(tServiceError.Build())
+
+	tIpAccessBatchRequest := rdl.NewStructTypeBuilder("Struct", "IpAccessBatchRequest")
+	tIpAccessBatchRequest.ArrayField("requests", "IpAccessRequestV2", false, "")
+	sb.AddType(tIpAccessBatchRequest.Build())
+
+	tIpAccessBatchResult := rdl.NewStructTypeBuilder("Struct", "IpAccessBatchResult")
+	tIpAccessBatchResult.Field("event_uuid", "String", false, nil, "")
+	tIpAccessBatchResult.Field("response", "IpAccessResponse", true, nil, "")
+	tIpAccessBatchResult.Field("error", "ServiceError", true, nil, "")
+	sb.AddType(tIpAccessBatchResult.Build())
+
+	tIpAccessBatchResponse := rdl.NewStructTypeBuilder("Struct", "IpAccessBatchResponse")
+	tIpAccessBatchResponse.ArrayField("results", "IpAccessBatchResult", false, "")
+	sb.AddType(tIpAccessBatchResponse.Build())
+
 	tIpAccessRecord := rdl.NewStructTypeBuilder("Struct", "IpAccessRecord")
 	tIpAccessRecord.Field("username", "String", false, nil, "")
-	tIpAccessRecord.Field("unix_timestamp", "Int32", false, nil, "")
+	tIpAccessRecord.Field("timestamp_ms", "Int64", false, nil, "")
 	tIpAccessRecord.Field("event_uuid", "String", false, nil, "")
 	tIpAccessRecord.Field("ip_address", "IPAddress", false, nil, "")
 	tIpAccessRecord.Field("lat", "Float64", false, nil, "")
 	tIpAccessRecord.Field("lon", "Float64", false, nil, "")
 	tIpAccessRecord.Field("radius", "Int32", false, nil, "")
+	tIpAccessRecord.Field("principal", "String", true, nil, "")
+	tIpAccessRecord.Field("provider", "String", true, nil, "")
+	tIpAccessRecord.Field("label", "String", true, nil, "")
+	tIpAccessRecord.Field("isTor", "Bool", true, nil, "")
+	tIpAccessRecord.Field("isAnonymizer", "Bool", true, nil, "")
+	tIpAccessRecord.Field("isHosting", "Bool", true, nil, "")
+	tIpAccessRecord.Field("asn", "Int64", true, nil, "")
+	tIpAccessRecord.Field("asOrganization", "String", true, nil, "")
+	tIpAccessRecord.Field("connectionType", "String", true, nil, "")
+	tIpAccessRecord.Field("countryCode", "String", true, nil, "")
+	tIpAccessRecord.Field("subdivision", "String", true, nil, "")
+	tIpAccessRecord.Field("city", "String", true, nil, "")
+	tIpAccessRecord.Field("timeZone", "String", true, nil, "")
 	sb.AddType(tIpAccessRecord.Build())
 
+	tIpAccessTimelineEntry := rdl.NewStructTypeBuilder("Struct", "IpAccessTimelineEntry")
+	tIpAccessTimelineEntry.Field("record", "IpAccessRecord", false, nil, "")
+	tIpAccessTimelineEntry.Field("travelToCurrentGeoSuspicious", "Bool", true, nil, "")
+	tIpAccessTimelineEntry.Field("travelFromCurrentGeoSuspicious", "Bool", true, nil, "")
+	tIpAccessTimelineEntry.Field("precedingIpAccess", "IpAccess", true, nil, "")
+	tIpAccessTimelineEntry.Field("subsequentIpAccess", "IpAccess", true, nil, "")
+	sb.AddType(tIpAccessTimelineEntry.Build())
+
+	tIpAccessTimeline := rdl.NewStructTypeBuilder("Struct", "IpAccessTimeline")
+	tIpAccessTimeline.Field("username", "String", false, nil, "")
+	tIpAccessTimeline.ArrayField("accesses", "IpAccessTimelineEntry", false, "")
+	tIpAccessTimeline.Field("next", "String", true, nil, "")
+	sb.AddType(tIpAccessTimeline.Build())
+
+	tIpAccessEvent := rdl.NewStructTypeBuilder("Struct", "IpAccessEvent")
+	tIpAccessEvent.Field("record", "IpAccessRecord", false, nil, "")
+	tIpAccessEvent.Field("verdict", "IpAccessResponse", false, nil, "")
+	sb.AddType(tIpAccessEvent.Build())
+
+	tSpeedUnit := rdl.NewEnumTypeBuilder("Enum", "SpeedUnit")
+	tSpeedUnit.Element("MPH", "")
+	tSpeedUnit.Element("KMH", "")
+	sb.AddType(tSpeedUnit.Build())
+
+	tSpeedPolicy := rdl.NewStructTypeBuilder("Struct", "SpeedPolicy")
+	tSpeedPolicy.Field("threshold", "Float64", false, nil, "")
+	tSpeedPolicy.Field("unit", "SpeedUnit", false, nil, "")
+	sb.AddType(tSpeedPolicy.Build())
+
+	tSpeedPolicyGroup := rdl.NewStructTypeBuilder("Struct", "SpeedPolicyGroup")
+	tSpeedPolicyGroup.Field("name", "String", false, nil, "")
+	tSpeedPolicyGroup.Field("policy", "SpeedPolicy", false, nil, "")
+	tSpeedPolicyGroup.ArrayField("usernames", "String", false, "")
+	sb.AddType(tSpeedPolicyGroup.Build())
+
+	tSpeedPolicies := rdl.NewStructTypeBuilder("Struct", "SpeedPolicies")
+	tSpeedPolicies.Field("defaultPolicy", "SpeedPolicy", false, nil, "")
+	tSpeedPolicies.ArrayField("groups", "SpeedPolicyGroup", false, "")
+	tSpeedPolicies.MapField("users", "String", "SpeedPolicy", false, "")
+	sb.AddType(tSpeedPolicies.Build())
+
+	tUserDataExport := rdl.NewStructTypeBuilder("Struct", "UserDataExport")
+	tUserDataExport.Field("username", "String", false, nil, "")
+	tUserDataExport.ArrayField("records", "IpAccessRecord", false, "")
+	tUserDataExport.Field("policy", "SpeedPolicy", true, nil, "")
+	tUserDataExport.ArrayField("groups", "String", false, "")
+	sb.AddType(tUserDataExport.Build())
+
+	tUserDataErasure := rdl.NewStructTypeBuilder("Struct", "UserDataErasure")
+	tUserDataErasure.Field("username", "String", false, nil, "")
+	tUserDataErasure.Field("records", "Int32", false, nil, "")
+	tUserDataErasure.Field("policy", "Bool", false, nil, "")
+	tUserDataErasure.ArrayField("groups", "String", false, "")
+	sb.AddType(tUserDataErasure.Build())
+
+	tRetentionStatus := rdl.NewStructTypeBuilder("Struct", "RetentionStatus")
+	tRetentionStatus.Field("retention", "String", true, nil, "")
+	tRetentionStatus.MapField("tenantRetention", "String", "String", false, "")
+	tRetentionStatus.Field("purged", "Int64", false, nil, "")
+	tRetentionStatus.MapField("purgedByTenant", "String", "Int64", false, "")
+	tRetentionStatus.Field("runs", "Int64", false, nil, "")
+	tRetentionStatus.Field("lastRun", "Timestamp", true, nil, "")
+	tRetentionStatus.Field("lastError", "String", true, nil, "")
+	sb.AddType(tRetentionStatus.Build())
+
+	tGeoDBStatus := rdl.NewStructTypeBuilder("Struct", "GeoDBStatus")
+	tGeoDBStatus.Field("path", "String", false, nil, "")
+	tGeoDBStatus.Field("databaseType", "String", false, nil, "")
+	tGeoDBStatus.Field("buildEpoch", "Int64", false, nil, "")
+	tGeoDBStatus.Field("loadedAt", "Timestamp", false, nil, "")
+	tGeoDBStatus.Field("lastError", "String", true, nil, "")
+	sb.AddType(tGeoDBStatus.Build())
+
+	tGeoOverrideStatus := rdl.NewStructTypeBuilder("Struct", "GeoOverrideStatus")
+	tGeoOverrideStatus.Field("path", "String", false, nil, "")
+	tGeoOverrideStatus.Field("entries", "Int32", false, nil, "")
+	tGeoOverrideStatus.Field("loadedAt", "Timestamp", false, nil, "")
+	tGeoOverrideStatus.Field("lastError", "String", true, nil, "")
+	sb.AddType(tGeoOverrideStatus.Build())
+
+	tServiceStatus := rdl.NewStructTypeBuilder("Struct", "ServiceStatus")
+	tServiceStatus.ArrayField("geoProviders", "String", false, "")
+	tServiceStatus.Field("geodb", "GeoDBStatus", true, nil, "")
+	tServiceStatus.Field("anonymousIpDb", "GeoDBStatus", true, nil, "")
+	tServiceStatus.Field("asnDb", "GeoDBStatus", true, nil, "")
+	tServiceStatus.Field("connectionTypeDb", "GeoDBStatus", true, nil, "")
+	tServiceStatus.Field("overrides", "GeoOverrideStatus", true, nil, "")
+	sb.AddType(tServiceStatus.Build())
+
 	mPostIpAccessRequest := rdl.NewResourceBuilder("IpAccessResponse", "POST", "/")
 	mPostIpAccessRequest.Name("postIpAccessRequest")
 	mPostIpAccessRequest.Input("request", "IpAccessRequest", false, "", "", false, nil, "")
-	mPostIpAccessRequest.Exception("BAD_REQUEST", "ResourceError", "")
-	mPostIpAccessRequest.Exception("NOT_FOUND", "ResourceError", "")
+	mPostIpAccessRequest.Auth("", "", true, "")
+	mPostIpAccessRequest.Exception("BAD_REQUEST", "ServiceError", "")
+	mPostIpAccessRequest.Exception("CONFLICT", "ServiceError", "")
+	mPostIpAccessRequest.Exception("NOT_FOUND", "ServiceError", "")
+	mPostIpAccessRequest.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mPostIpAccessRequest.Exception("UNAUTHORIZED", "ResourceError", "")
+	mPostIpAccessRequest.Exception("UNPROCESSABLE_ENTITY", "ServiceError", "")
 	sb.AddResource(mPostIpAccessRequest.Build())
 
+	mPostIpAccessRequestV2 := rdl.NewResourceBuilder("IpAccessResponse", "POST", "/v2")
+	mPostIpAccessRequestV2.Name("postIpAccessRequestV2")
+	mPostIpAccessRequestV2.Input("request", "IpAccessRequestV2", false, "", "", false, nil, "")
+	mPostIpAccessRequestV2.Auth("", "", true, "")
+	mPostIpAccessRequestV2.Exception("BAD_REQUEST", "ServiceError", "")
+	mPostIpAccessRequestV2.Exception("CONFLICT", "ServiceError", "")
+	mPostIpAccessRequestV2.Exception("NOT_FOUND", "ServiceError", "")
+	mPostIpAccessRequestV2.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mPostIpAccessRequestV2.Exception("UNAUTHORIZED", "ResourceError", "")
+	mPostIpAccessRequestV2.Exception("UNPROCESSABLE_ENTITY", "ServiceError", "")
+	sb.AddResource(mPostIpAccessRequestV2.Build())
+
+	mPostIpAccessBatchRequest := rdl.NewResourceBuilder("IpAccessBatchResponse", "POST", "/batch")
+	mPostIpAccessBatchRequest.Name("postIpAccessBatchRequest")
+	mPostIpAccessBatchRequest.Input("batch", "IpAccessBatchRequest", false, "", "", false, nil, "")
+	mPostIpAccessBatchRequest.Auth("", "", true, "")
+	mPostIpAccessBatchRequest.Exception("BAD_REQUEST", "ServiceError", "")
+	mPostIpAccessBatchRequest.Exception("CONFLICT", "ServiceError", "")
+	mPostIpAccessBatchRequest.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mPostIpAccessBatchRequest.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mPostIpAccessBatchRequest.Build())
+
+	mEvaluateIpAccessRequest := rdl.NewResourceBuilder("IpAccessResponse", "POST", "/evaluate")
+	mEvaluateIpAccessRequest.Name("evaluateIpAccessRequest")
+	mEvaluateIpAccessRequest.Input("request", "IpAccessRequestV2", false, "", "", false, nil, "")
+	mEvaluateIpAccessRequest.Auth("", "", true, "")
+	mEvaluateIpAccessRequest.Exception("BAD_REQUEST", "ServiceError", "")
+	mEvaluateIpAccessRequest.Exception("NOT_FOUND", "ServiceError", "")
+	mEvaluateIpAccessRequest.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mEvaluateIpAccessRequest.Exception("UNAUTHORIZED", "ResourceError", "")
+	mEvaluateIpAccessRequest.Exception("UNPROCESSABLE_ENTITY", "ServiceError", "")
+	sb.AddResource(mEvaluateIpAccessRequest.Build())
+
+	mGetIpAccessEvent := rdl.NewResourceBuilder("IpAccessEvent", "GET", "/events/{uuid}")
+	mGetIpAccessEvent.Name("getIpAccessEvent")
+	mGetIpAccessEvent.Input("uuid", "String", true, "", "", false, nil, "")
+	mGetIpAccessEvent.Auth("read", "users", false, "")
+	mGetIpAccessEvent.Exception("FORBIDDEN", "ResourceError", "")
+	mGetIpAccessEvent.Exception("NOT_FOUND", "ServiceError", "")
+	mGetIpAccessEvent.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mGetIpAccessEvent.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mGetIpAccessEvent.Build())
+
+	mGetUserIpAccessTimeline := rdl.NewResourceBuilder("IpAccessTimeline", "GET", "/users/{username}/accesses")
+	mGetUserIpAccessTimeline.Name("getUserIpAccessTimeline")
+	mGetUserIpAccessTimeline.Input("username", "String", true, "", "", false, nil, "")
+	mGetUserIpAccessTimeline.Input("from", "Int64", false, "from", "", true, nil, "")
+	mGetUserIpAccessTimeline.Input("to", "Int64", false, "to", "", true, nil, "")
+	mGetUserIpAccessTimeline.Input("limit", "Int32", false, "limit", "", false, 100, "")
+	mGetUserIpAccessTimeline.Input("next", "String", false, "next", "", true, nil, "")
+	mGetUserIpAccessTimeline.Auth("read", "users", false, "")
+	mGetUserIpAccessTimeline.Exception("BAD_REQUEST", "ServiceError", "")
+	mGetUserIpAccessTimeline.Exception("FORBIDDEN", "ResourceError", "")
+	mGetUserIpAccessTimeline.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mGetUserIpAccessTimeline.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mGetUserIpAccessTimeline.Build())
+
+	mGetUserDataExport := rdl.NewResourceBuilder("UserDataExport", "GET", "/users/{username}/export")
+	mGetUserDataExport.Name("getUserDataExport")
+	mGetUserDataExport.Input("username", "String", true, "", "", false, nil, "")
+	mGetUserDataExport.Input("format", "String", false, "format", "", false, "json", "")
+	mGetUserDataExport.Auth("read", "users", false, "")
+	mGetUserDataExport.Exception("BAD_REQUEST", "ServiceError", "")
+	mGetUserDataExport.Exception("FORBIDDEN", "ResourceError", "")
+	mGetUserDataExport.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mGetUserDataExport.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mGetUserDataExport.Build())
+
+	mDeleteUserData := rdl.NewResourceBuilder("UserDataErasure", "DELETE", "/users/{username}")
+	mDeleteUserData.Name("deleteUserData")
+	mDeleteUserData.Input("username", "String", true, "", "", false, nil, "")
+	mDeleteUserData.Auth("delete", "users", false, "")
+	mDeleteUserData.Exception("FORBIDDEN", "ResourceError", "")
+	mDeleteUserData.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mDeleteUserData.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mDeleteUserData.Build())
+
+	mGetServiceStatus := rdl.NewResourceBuilder("ServiceStatus", "GET", "/status")
+	mGetServiceStatus.Name("getServiceStatus")
+	mGetServiceStatus.Auth("", "", true, "")
+	mGetServiceStatus.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mGetServiceStatus.Build())
+
+	mGetRetentionStatus := rdl.NewResourceBuilder("RetentionStatus", "GET", "/retention")
+	mGetRetentionStatus.Name("getRetentionStatus")
+	mGetRetentionStatus.Auth("", "", true, "")
+	mGetRetentionStatus.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mGetRetentionStatus.Build())
+
+	mGetSpeedPolicies := rdl.NewResourceBuilder("SpeedPolicies", "GET", "/policies")
+	mGetSpeedPolicies.Name("getSpeedPolicies")
+	mGetSpeedPolicies.Auth("", "", true, "")
+	mGetSpeedPolicies.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mGetSpeedPolicies.Build())
+
+	mPutUserSpeedPolicy := rdl.NewResourceBuilder("SpeedPolicy", "PUT", "/policies/users/{username}")
+	mPutUserSpeedPolicy.Name("putUserSpeedPolicy")
+	mPutUserSpeedPolicy.Input("username", "String", true, "", "", false, nil, "")
+	mPutUserSpeedPolicy.Input("policy", "SpeedPolicy", false, "", "", false, nil, "")
+	mPutUserSpeedPolicy.Auth("update", "policies", false, "")
+	mPutUserSpeedPolicy.Exception("BAD_REQUEST", "ServiceError", "")
+	mPutUserSpeedPolicy.Exception("FORBIDDEN", "ResourceError", "")
+	mPutUserSpeedPolicy.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mPutUserSpeedPolicy.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mPutUserSpeedPolicy.Build())
+
+	mDeleteUserSpeedPolicy := rdl.NewResourceBuilder("SpeedPolicy", "DELETE", "/policies/users/{username}")
+	mDeleteUserSpeedPolicy.Name("deleteUserSpeedPolicy")
+	mDeleteUserSpeedPolicy.Input("username", "String", true, "", "", false, nil, "")
+	mDeleteUserSpeedPolicy.Auth("delete", "policies", false, "")
+	mDeleteUserSpeedPolicy.Expected("NO_CONTENT")
+	mDeleteUserSpeedPolicy.Exception("FORBIDDEN", "ResourceError", "")
+	mDeleteUserSpeedPolicy.Exception("NOT_FOUND", "ServiceError", "")
+	mDeleteUserSpeedPolicy.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mDeleteUserSpeedPolicy.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mDeleteUserSpeedPolicy.Build())
+
+	mPutSpeedPolicyGroup := rdl.NewResourceBuilder("SpeedPolicyGroup", "PUT", "/policies/groups/{name}")
+	mPutSpeedPolicyGroup.Name("putSpeedPolicyGroup")
+	mPutSpeedPolicyGroup.Input("name", "String", true, "", "", false, nil, "")
+	mPutSpeedPolicyGroup.Input("group", "SpeedPolicyGroup", false, "", "", false, nil, "")
+	mPutSpeedPolicyGroup.Auth("update", "policies", false, "")
+	mPutSpeedPolicyGroup.Exception("BAD_REQUEST", "ServiceError", "")
+	mPutSpeedPolicyGroup.Exception("FORBIDDEN", "ResourceError", "")
+	mPutSpeedPolicyGroup.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mPutSpeedPolicyGroup.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mPutSpeedPolicyGroup.Build())
+
+	mDeleteSpeedPolicyGroup := rdl.NewResourceBuilder("SpeedPolicyGroup", "DELETE", "/policies/groups/{name}")
+	mDeleteSpeedPolicyGroup.Name("deleteSpeedPolicyGroup")
+	mDeleteSpeedPolicyGroup.Input("name", "String", true, "", "", false, nil, "")
+	mDeleteSpeedPolicyGroup.Auth("delete", "policies", false, "")
+	mDeleteSpeedPolicyGroup.Expected("NO_CONTENT")
+	mDeleteSpeedPolicyGroup.Exception("FORBIDDEN", "ResourceError", "")
+	mDeleteSpeedPolicyGroup.Exception("NOT_FOUND", "ServiceError", "")
+	mDeleteSpeedPolicyGroup.Exception("SERVICE_UNAVAILABLE", "ServiceError", "")
+	mDeleteSpeedPolicyGroup.Exception("UNAUTHORIZED", "ResourceError", "")
+	sb.AddResource(mDeleteSpeedPolicyGroup.Build())
+
 	var err error
 	schema, err = sb.BuildParanoid()
 	if err != nil {
@@ -36,6 +36,48 @@ func Init(impl SupermanDetectorHandler, baseURL string, authz rdl.Authorizer, au
 	router.POST(b+"/", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
 		adaptor.postIpAccessRequestHandler(w, r, ps)
 	})
+	router.POST(b+"/v2", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.postIpAccessRequestV2Handler(w, r, ps)
+	})
+	router.POST(b+"/batch", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.postIpAccessBatchRequestHandler(w, r, ps)
+	})
+	router.POST(b+"/evaluate", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.evaluateIpAccessRequestHandler(w, r, ps)
+	})
+	router.GET(b+"/events/:uuid", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.getIpAccessEventHandler(w, r, ps)
+	})
+	router.GET(b+"/users/:username/accesses", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.getUserIpAccessTimelineHandler(w, r, ps)
+	})
+	router.GET(b+"/users/:username/export", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.getUserDataExportHandler(w, r, ps)
+	})
+	router.DELETE(b+"/users/:username", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.deleteUserDataHandler(w, r, ps)
+	})
+	router.GET(b+"/status", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.getServiceStatusHandler(w, r, ps)
+	})
+	router.GET(b+"/retention", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.getRetentionStatusHandler(w, r, ps)
+	})
+	router.GET(b+"/policies", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.getSpeedPoliciesHandler(w, r, ps)
+	})
+	router.PUT(b+"/policies/users/:username", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.putUserSpeedPolicyHandler(w, r, ps)
+	})
+	router.DELETE(b+"/policies/users/:username", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.deleteUserSpeedPolicyHandler(w, r, ps)
+	})
+	router.PUT(b+"/policies/groups/:name", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.putSpeedPolicyGroupHandler(w, r, ps)
+	})
+	router.DELETE(b+"/policies/groups/:name", func(w http.ResponseWriter, r *http.Request, ps map[string]string) {
+		adaptor.deleteSpeedPolicyGroupHandler(w, r, ps)
+	})
 	router.NotFoundHandler = func(w http.ResponseWriter, r *http.Request) {
 		rdl.JSONResponse(w, 404, rdl.ResourceError{Code: http.StatusNotFound, Message: "Not Found"})
 	}
@@ -48,6 +90,20 @@ func Init(impl SupermanDetectorHandler, baseURL string, authz rdl.Authorizer, au
 //
 type SupermanDetectorHandler interface {
 	PostIpAccessRequest(context *rdl.ResourceContext, request *IpAccessRequest) (*IpAccessResponse, error)
+	PostIpAccessRequestV2(context *rdl.ResourceContext, request *IpAccessRequestV2) (*IpAccessResponse, error)
+	PostIpAccessBatchRequest(context *rdl.ResourceContext, batch *IpAccessBatchRequest) (*IpAccessBatchResponse, error)
+	EvaluateIpAccessRequest(context *rdl.ResourceContext, request *IpAccessRequestV2) (*IpAccessResponse, error)
+	GetIpAccessEvent(context *rdl.ResourceContext, uuid string) (*IpAccessEvent, error)
+	GetUserIpAccessTimeline(context *rdl.ResourceContext, username string, from *int64, to *int64, limit int32, next string) (*IpAccessTimeline, error)
+	GetUserDataExport(context *rdl.ResourceContext, username string, format string) (*UserDataExport, error)
+	DeleteUserData(context *rdl.ResourceContext, username string) (*UserDataErasure, error)
+	GetServiceStatus(context *rdl.ResourceContext) (*ServiceStatus, error)
+	GetRetentionStatus(context *rdl.ResourceContext) (*RetentionStatus, error)
+	GetSpeedPolicies(context *rdl.ResourceContext) (*SpeedPolicies, error)
+	PutUserSpeedPolicy(context *rdl.ResourceContext, username string, policy *SpeedPolicy) (*SpeedPolicy, error)
+	DeleteUserSpeedPolicy(context *rdl.ResourceContext, username string) error
+	PutSpeedPolicyGroup(context *rdl.ResourceContext, name string, group *SpeedPolicyGroup) (*SpeedPolicyGroup, error)
+	DeleteSpeedPolicyGroup(context *rdl.ResourceContext, name string) error
 	Authenticate(context *rdl.ResourceContext) bool
 }
 
@@ -126,6 +182,10 @@ func floatFromString(s string) float64 {
 
 func (adaptor SupermanDetectorAdaptor) postIpAccessRequestHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
 	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authenticate(context) {
+		rdl.JSONResponse(writer, http.StatusUnauthorized, rdl.ResourceError{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
 	var argRequest *IpAccessRequest
 	oserr := json.NewDecoder(request.Body).Decode(&argRequest)
 	if oserr != nil {
@@ -145,3 +205,338 @@ func (adaptor SupermanDetectorAdaptor) postIpAccessRequestHandler(writer http.Re
 	}
 
 }
+
+func (adaptor SupermanDetectorAdaptor) postIpAccessRequestV2Handler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authenticate(context) {
+		rdl.JSONResponse(writer, http.StatusUnauthorized, rdl.ResourceError{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	var argRequest *IpAccessRequestV2
+	oserr := json.NewDecoder(request.Body).Decode(&argRequest)
+	if oserr != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + oserr.Error()})
+		return
+	}
+	data, err := adaptor.impl.PostIpAccessRequestV2(context, argRequest)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) postIpAccessBatchRequestHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authenticate(context) {
+		rdl.JSONResponse(writer, http.StatusUnauthorized, rdl.ResourceError{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	var argBatch *IpAccessBatchRequest
+	oserr := json.NewDecoder(request.Body).Decode(&argBatch)
+	if oserr != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + oserr.Error()})
+		return
+	}
+	data, err := adaptor.impl.PostIpAccessBatchRequest(context, argBatch)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) evaluateIpAccessRequestHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authenticate(context) {
+		rdl.JSONResponse(writer, http.StatusUnauthorized, rdl.ResourceError{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	var argRequest *IpAccessRequestV2
+	oserr := json.NewDecoder(request.Body).Decode(&argRequest)
+	if oserr != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + oserr.Error()})
+		return
+	}
+	data, err := adaptor.impl.EvaluateIpAccessRequest(context, argRequest)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) getIpAccessEventHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "read", "users") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argUuid := context.Params["uuid"]
+	data, err := adaptor.impl.GetIpAccessEvent(context, argUuid)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) getUserIpAccessTimelineHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "read", "users") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argUsername := context.Params["username"]
+	argFrom, err := rdl.OptionalInt64Param(request, "from")
+	if err != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + err.Error()})
+		return
+	}
+	argTo, err := rdl.OptionalInt64Param(request, "to")
+	if err != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + err.Error()})
+		return
+	}
+	argLimit, err := rdl.Int32Param(request, "limit", 100)
+	if err != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + err.Error()})
+		return
+	}
+	argNext := rdl.OptionalStringParam(request, "next")
+	data, err := adaptor.impl.GetUserIpAccessTimeline(context, argUsername, argFrom, argTo, argLimit, argNext)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) getUserDataExportHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "read", "users") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argUsername := context.Params["username"]
+	argFormat, _ := rdl.StringParam(request, "format", "json")
+	data, err := adaptor.impl.GetUserDataExport(context, argUsername, argFormat)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) deleteUserDataHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "delete", "users") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argUsername := context.Params["username"]
+	data, err := adaptor.impl.DeleteUserData(context, argUsername)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) getServiceStatusHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authenticate(context) {
+		rdl.JSONResponse(writer, http.StatusUnauthorized, rdl.ResourceError{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	data, err := adaptor.impl.GetServiceStatus(context)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) getRetentionStatusHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authenticate(context) {
+		rdl.JSONResponse(writer, http.StatusUnauthorized, rdl.ResourceError{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	data, err := adaptor.impl.GetRetentionStatus(context)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) getSpeedPoliciesHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authenticate(context) {
+		rdl.JSONResponse(writer, http.StatusUnauthorized, rdl.ResourceError{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	data, err := adaptor.impl.GetSpeedPolicies(context)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) putUserSpeedPolicyHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "update", "policies") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argUsername := context.Params["username"]
+	var argPolicy *SpeedPolicy
+	oserr := json.NewDecoder(request.Body).Decode(&argPolicy)
+	if oserr != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + oserr.Error()})
+		return
+	}
+	data, err := adaptor.impl.PutUserSpeedPolicy(context, argUsername, argPolicy)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) deleteUserSpeedPolicyHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "delete", "policies") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argUsername := context.Params["username"]
+	err := adaptor.impl.DeleteUserSpeedPolicy(context, argUsername)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 204, nil)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) putSpeedPolicyGroupHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "update", "policies") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argName := context.Params["name"]
+	var argGroup *SpeedPolicyGroup
+	oserr := json.NewDecoder(request.Body).Decode(&argGroup)
+	if oserr != nil {
+		rdl.JSONResponse(writer, http.StatusBadRequest, rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request: " + oserr.Error()})
+		return
+	}
+	data, err := adaptor.impl.PutSpeedPolicyGroup(context, argName, argGroup)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 200, data)
+	}
+
+}
+
+func (adaptor SupermanDetectorAdaptor) deleteSpeedPolicyGroupHandler(writer http.ResponseWriter, request *http.Request, params map[string]string) {
+	context := &rdl.ResourceContext{Writer: writer, Request: request, Params: params, Principal: nil}
+	if !adaptor.authorize(context, "delete", "policies") {
+		rdl.JSONResponse(writer, http.StatusForbidden, rdl.ResourceError{Code: http.StatusForbidden, Message: "Forbidden"})
+		return
+	}
+	argName := context.Params["name"]
+	err := adaptor.impl.DeleteSpeedPolicyGroup(context, argName)
+	if err != nil {
+		switch e := err.(type) {
+		case *rdl.ResourceError:
+			rdl.JSONResponse(writer, e.Code, err)
+		default:
+			rdl.JSONResponse(writer, 500, &rdl.ResourceError{Code: 500, Message: e.Error()})
+		}
+	} else {
+		rdl.JSONResponse(writer, 204, nil)
+	}
+
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// certReloader is an implementation to serve the certificate and the client CA reloaded from the files whenever they are modified
+type certReloader struct {
+	mu           sync.Mutex
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+	modTimes     []time.Time
+	config       *tls.Config
+}
+
+// ParseClientAuth is an implementation to parse the client certificate verification mode in the configuration
+func ParseClientAuth(s string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown tls client auth: %s", s)
+	}
+}
+
+// NewTLSConfig is an implementation to initialize a tls.Config serving the certificate of the configuration with hot reload
+func NewTLSConfig(config *Config) (*tls.Config, error) {
+	clientAuth, err := ParseClientAuth(config.TLSClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	if clientAuth != tls.NoClientCert && config.TLSClientCA == "" {
+		return nil, fmt.Errorf("tls client auth %s requires a client CA", config.TLSClientAuth)
+	}
+
+	r := &certReloader{
+		certFile:     config.TLSCert,
+		keyFile:      config.TLSKey,
+		clientCAFile: config.TLSClientCA,
+		clientAuth:   clientAuth,
+	}
+	modTimes, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	err = r.reload(modTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		NextProtos:         []string{"h2", "http/1.1"},
+		GetConfigForClient: r.getConfigForClient,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			c, _ := r.getConfigForClient(hello)
+			return &c.Certificates[0], nil
+		},
+	}, nil
+}
+
+// getConfigForClient returns the current tls.Config, reloading the files first when any of them is modified
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes, err := r.stat()
+	if err != nil {
+		log.Printf("Failed to stat certificate, keep serving the current one, Error:%v\n", err)
+		return r.config, nil
+	}
+	for i := range modTimes {
+		if !modTimes[i].Equal(r.modTimes[i]) {
+			err = r.reload(modTimes)
+			if err != nil {
+				log.Printf("Failed to reload certificate, keep serving the current one, Error:%v\n", err)
+			} else {
+				log.Printf("Reloaded certificate from %s\n", r.certFile)
+			}
+			break
+		}
+	}
+
+	return r.config, nil
+}
+
+func (r *certReloader) stat() ([]time.Time, error) {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+
+	modTimes := make([]time.Time, 0, len(files))
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, fi.ModTime())
+	}
+
+	return modTimes, nil
+}
+
+func (r *certReloader) reload(modTimes []time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+	}
+	if r.clientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.clientCAFile)
+		if err != nil {
+			return err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in %s", r.clientCAFile)
+		}
+	}
+
+	r.config = config
+	r.modTimes = modTimes
+
+	return nil
+}
+
+// PrincipalFromTLS is an implementation to get the principal of the verified client certificate, or nil if there is none.
+// The common name is the name of the principal, and the first organizational unit if any is the domain.
+func PrincipalFromTLS(request *http.Request) rdl.Principal {
+	if request == nil || request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := request.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName == "" {
+		return nil
+	}
+	principal := &SimplePrincipal{
+		Name: cert.Subject.CommonName,
+	}
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		principal.Domain = cert.Subject.OrganizationalUnit[0]
+	}
+
+	return principal
+}
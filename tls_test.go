@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func writeTestCert(t *testing.T, certFile, keyFile string, subject pkix.Name) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               subject,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, pkix.Name{CommonName: "first"})
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+	}{
+		{
+			name:   "Test case 1 (server certificate only)",
+			config: &Config{TLSCert: certFile, TLSKey: keyFile},
+		},
+		{
+			name:   "Test case 2 (client certificate required)",
+			config: &Config{TLSCert: certFile, TLSKey: keyFile, TLSClientCA: certFile, TLSClientAuth: "require"},
+		},
+		{
+			name:    "Test case 3 (client certificate required without client CA)",
+			config:  &Config{TLSCert: certFile, TLSKey: keyFile, TLSClientAuth: "require"},
+			wantErr: true,
+		},
+		{
+			name:    "Test case 4 (unknown client auth)",
+			config:  &Config{TLSCert: certFile, TLSKey: keyFile, TLSClientCA: certFile, TLSClientAuth: "always"},
+			wantErr: true,
+		},
+		{
+			name:    "Test case 5 (missing certificate)",
+			config:  &Config{TLSCert: filepath.Join(dir, "missing.pem"), TLSKey: keyFile},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTLSConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSConfigReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, pkix.Name{CommonName: "first"})
+
+	config, err := NewTLSConfig(&Config{TLSCert: certFile, TLSKey: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commonName := func() string {
+		c, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(c.Certificates[0].Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.Subject.CommonName
+	}
+	if got := commonName(); got != "first" {
+		t.Fatalf("common name = %v, want first", got)
+	}
+
+	writeTestCert(t, certFile, keyFile, pkix.Name{CommonName: "second"})
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	if got := commonName(); got != "second" {
+		t.Errorf("common name = %v, want second", got)
+	}
+
+	// a broken certificate keeps the current one
+	ioutil.WriteFile(certFile, []byte("broken"), 0600)
+	later = later.Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	if got := commonName(); got != "second" {
+		t.Errorf("common name = %v, want second", got)
+	}
+}
+
+func TestPrincipalFromTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *http.Request
+		want    rdl.Principal
+	}{
+		{
+			name:    "Test case 1 (plain http)",
+			request: &http.Request{},
+			want:    nil,
+		},
+		{
+			name:    "Test case 2 (no client certificate)",
+			request: &http.Request{TLS: &tls.ConnectionState{}},
+			want:    nil,
+		},
+		{
+			name: "Test case 3 (common name)",
+			request: &http.Request{TLS: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+				{Subject: pkix.Name{CommonName: "collector"}},
+			}}}},
+			want: &SimplePrincipal{Name: "collector"},
+		},
+		{
+			name: "Test case 4 (common name and organizational unit)",
+			request: &http.Request{TLS: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+				{Subject: pkix.Name{CommonName: "collector", OrganizationalUnit: []string{"security"}}},
+			}}}},
+			want: &SimplePrincipal{Domain: "security", Name: "collector"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrincipalFromTLS(tt.request); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PrincipalFromTLS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}